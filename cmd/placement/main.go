@@ -48,6 +48,10 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// The replication factor must be set before Raft starts, since restoring
+	// a snapshot rebuilds the hashing tables.
+	hashing.SetReplicationFactor(cfg.replicationFactor)
+
 	// Start Raft cluster.
 	raftServer := raft.New(cfg.raftID, cfg.raftInMemEnabled, cfg.raftPeers, cfg.raftLogStorePath)
 	if raftServer == nil {
@@ -57,9 +61,9 @@ func main() {
 	if err := raftServer.StartRaft(nil); err != nil {
 		log.Fatalf("failed to start Raft Server: %v", err)
 	}
+	raftServer.FSM().State().SetMutationMetrics(monitoring.MutationRecorder{})
 
 	// Start Placement gRPC server.
-	hashing.SetReplicationFactor(cfg.replicationFactor)
 	apiServer := placement.NewPlacementService(raftServer)
 	var certChain *credentials.CertChain
 	if cfg.tlsEnabled {
@@ -359,6 +359,8 @@ func (p *ActorPlacement) WaitUntilPlacementTableIsReady() {
 }
 
 // LookupActor resolves to actor service instance address using consistent hashing table.
+// The pins and aliases of the placement state are not disseminated, so they
+// don't apply here.
 func (p *ActorPlacement) LookupActor(actorType, actorID string) (string, string) {
 	if p.placementTables == nil {
 		return "", ""
@@ -28,6 +28,10 @@ import (
 
 var replicationFactor int
 
+// defaultLoadFactor is the default factor of the average load which bounds
+// the load of a single host.
+const defaultLoadFactor = 1.25
+
 // ErrNoHosts is an error for no hosts
 var ErrNoHosts = errors.New("no hosts added")
 
@@ -39,22 +43,85 @@ type ConsistentHashTables struct {
 
 // Host represents a host of stateful entities with a given name, id, port and load
 type Host struct {
-	Name  string
-	Port  int64
-	Load  int64
-	AppID string
+	Name   string
+	Port   int64
+	Load   int64
+	AppID  string
+	Weight int
+}
+
+// RingPoint is a virtual node on the ring owned by the host
+type RingPoint struct {
+	Hash uint64
+	Host string
+}
+
+// Range is a part of the ring. It holds the hashes after Start up to and
+// including End, and wraps around when End < Start. Start == End is the
+// whole ring.
+type Range struct {
+	Start uint64
+	End   uint64
 }
 
+// String returns the range as hex encoded start and end hashes
+func (r Range) String() string {
+	return fmt.Sprintf("%016x-%016x", r.Start, r.End)
+}
+
+// HashFunc is a function hashing the key into the ring
+type HashFunc func(key []byte) uint64
+
+// Option is a function that applies an option to the consistent hash
+type Option func(c *Consistent)
+
 // Consistent represents a data structure for consistent hashing
 type Consistent struct {
-	hosts     map[uint64]string
-	sortedSet []uint64
-	loadMap   map[string]*Host
-	totalLoad int64
+	hosts      map[uint64]string
+	sortedSet  []uint64
+	loadMap    map[string]*Host
+	totalLoad  int64
+	hashFunc   HashFunc
+	replicas   int
+	loadFactor float64
 
 	sync.RWMutex
 }
 
+// WithHashFunc sets the hash function of the ring. nil keeps the default blake2b based hash.
+// The hash function isn't part of the placement tables, and NewFromExisting
+// always uses the default hash, so rings disseminated to Dapr runtimes must
+// keep the default.
+func WithHashFunc(fn HashFunc) Option {
+	return func(c *Consistent) {
+		if fn != nil {
+			c.hashFunc = fn
+		}
+	}
+}
+
+// WithReplicationFactor sets the number of virtual nodes per host of the ring.
+// Zero or less uses the replication factor set by SetReplicationFactor at the
+// time a host is added.
+func WithReplicationFactor(replicas int) Option {
+	return func(c *Consistent) {
+		if replicas > 0 {
+			c.replicas = replicas
+		}
+	}
+}
+
+// WithLoadFactor sets the factor of the average load which bounds the load of
+// a single host in GetLeast. The factor must be greater than one, since no
+// host may be under a lower bound, and other factors keep the default of 1.25.
+func WithLoadFactor(factor float64) Option {
+	return func(c *Consistent) {
+		if factor > 1 {
+			c.loadFactor = factor
+		}
+	}
+}
+
 // NewPlacementTables returns new stateful placement tables with a given version
 func NewPlacementTables(version string, entries map[string]*Consistent) *ConsistentHashTables {
 	return &ConsistentHashTables{
@@ -74,20 +141,52 @@ func NewHost(name, id string, load int64, port int64) *Host {
 }
 
 // NewConsistentHash returns a new consistent hash
-func NewConsistentHash() *Consistent {
-	return &Consistent{
-		hosts:     map[uint64]string{},
-		sortedSet: []uint64{},
-		loadMap:   map[string]*Host{},
+func NewConsistentHash(opts ...Option) *Consistent {
+	c := &Consistent{
+		hosts:      map[uint64]string{},
+		sortedSet:  []uint64{},
+		loadMap:    map[string]*Host{},
+		hashFunc:   defaultHash,
+		loadFactor: defaultLoadFactor,
 	}
+
+	for _, o := range opts {
+		o(c)
+	}
+	return c
 }
 
 // NewFromExisting creates a new consistent hash from existing values
 func NewFromExisting(hosts map[uint64]string, sortedSet []uint64, loadMap map[string]*Host) *Consistent {
 	return &Consistent{
-		hosts:     hosts,
-		sortedSet: sortedSet,
-		loadMap:   loadMap,
+		hosts:      hosts,
+		sortedSet:  sortedSet,
+		loadMap:    loadMap,
+		hashFunc:   defaultHash,
+		loadFactor: defaultLoadFactor,
+	}
+}
+
+// Reset removes all hosts from the ring and applies the options, so that
+// the ring can be reused without reallocating its internal storage.
+func (c *Consistent) Reset(opts ...Option) {
+	c.Lock()
+	defer c.Unlock()
+
+	for k := range c.hosts {
+		delete(c.hosts, k)
+	}
+	for k := range c.loadMap {
+		delete(c.loadMap, k)
+	}
+	c.sortedSet = c.sortedSet[:0]
+	c.totalLoad = 0
+	c.hashFunc = defaultHash
+	c.replicas = 0
+	c.loadFactor = defaultLoadFactor
+
+	for _, o := range opts {
+		o(c)
 	}
 }
 
@@ -101,6 +200,13 @@ func (c *Consistent) GetInternals() (map[uint64]string, []uint64, map[string]*Ho
 
 // Add adds a host with port to the table
 func (c *Consistent) Add(host, id string, port int64) bool {
+	return c.AddWithWeight(host, id, port, 0)
+}
+
+// AddWithWeight adds a host with port to the table. The number of virtual
+// nodes for the host is scaled by weight; weight zero or one places the
+// default number of virtual nodes.
+func (c *Consistent) AddWithWeight(host, id string, port int64, weight int) bool {
 	c.Lock()
 	defer c.Unlock()
 
@@ -108,8 +214,8 @@ func (c *Consistent) Add(host, id string, port int64) bool {
 		return true
 	}
 
-	c.loadMap[host] = &Host{Name: host, AppID: id, Load: 0, Port: port}
-	for i := 0; i < replicationFactor; i++ {
+	c.loadMap[host] = &Host{Name: host, AppID: id, Load: 0, Port: port, Weight: weight}
+	for i := 0; i < c.vnodeCount(weight); i++ {
 		h := c.hash(fmt.Sprintf("%s%d", host, i))
 		c.hosts[h] = host
 		c.sortedSet = append(c.sortedSet, h)
@@ -156,8 +262,7 @@ func (c *Consistent) GetHost(key string) (*Host, error) {
 //
 // to pick the least loaded host that can serve the key
 //
-// It returns ErrNoHosts if the ring has no hosts in it.
-//
+// It returns the owner of the key if every host is over the bound, and ErrNoHosts if the ring has no hosts in it.
 func (c *Consistent) GetLeast(key string) (string, error) {
 	c.RLock()
 	defer c.RUnlock()
@@ -169,17 +274,44 @@ func (c *Consistent) GetLeast(key string) (string, error) {
 	h := c.hash(key)
 	idx := c.search(h)
 
+	// the scan goes around the ring once, and falls back to the owner of the
+	// key when every host is over the bound.
 	i := idx
-	for {
+	for n := 0; n < len(c.sortedSet); n++ {
 		host := c.hosts[c.sortedSet[i]]
 		if c.loadOK(host) {
 			return host, nil
 		}
 		i++
-		if i >= len(c.hosts) {
+		if i >= len(c.sortedSet) {
 			i = 0
 		}
 	}
+	return c.hosts[c.sortedSet[idx]], nil
+}
+
+// GetN returns up to n distinct hosts clockwise from `key`, starting with the
+// host that owns `key`. It returns fewer hosts if the ring has less than n hosts.
+func (c *Consistent) GetN(key string, n int) []string {
+	c.RLock()
+	defer c.RUnlock()
+
+	hosts := []string{}
+	if len(c.sortedSet) == 0 || n <= 0 {
+		return hosts
+	}
+
+	seen := map[string]struct{}{}
+	idx := c.search(c.hash(key))
+	for i := 0; i < len(c.sortedSet) && len(hosts) < n; i++ {
+		host := c.hosts[c.sortedSet[(idx+i)%len(c.sortedSet)]]
+		if _, ok := seen[host]; ok {
+			continue
+		}
+		seen[host] = struct{}{}
+		hosts = append(hosts, host)
+	}
+	return hosts
 }
 
 func (c *Consistent) search(key uint64) int {
@@ -236,7 +368,12 @@ func (c *Consistent) Remove(host string) bool {
 	c.Lock()
 	defer c.Unlock()
 
-	for i := 0; i < replicationFactor; i++ {
+	weight := 0
+	if h, ok := c.loadMap[host]; ok {
+		weight = h.Weight
+	}
+
+	for i := 0; i < c.vnodeCount(weight); i++ {
 		h := c.hash(fmt.Sprintf("%s%d", host, i))
 		delete(c.hosts, h)
 		c.delSlice(h)
@@ -245,6 +382,187 @@ func (c *Consistent) Remove(host string) bool {
 	return true
 }
 
+// UpdateAppID sets the app id of host without changing the ring.
+// It returns false if the host is not in the ring.
+func (c *Consistent) UpdateAppID(host, id string) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	h, ok := c.loadMap[host]
+	if !ok {
+		return false
+	}
+	h.AppID = id
+	return true
+}
+
+// SuccessorHosts returns the distinct hosts which take over the virtual nodes
+// of the given host once it is removed from the ring, in sorted order.
+func (c *Consistent) SuccessorHosts(host string) []string {
+	successors := map[string]struct{}{}
+	for _, next := range c.RangeSuccessors(host) {
+		successors[next] = struct{}{}
+	}
+
+	hosts := make([]string, 0, len(successors))
+	for k := range successors {
+		hosts = append(hosts, k)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// RangeSuccessors returns, per range owned by the given host, the host which
+// takes over the range once the given host is removed from the ring.
+func (c *Consistent) RangeSuccessors(host string) map[Range]string {
+	c.RLock()
+	defer c.RUnlock()
+
+	successors := map[Range]string{}
+	n := len(c.sortedSet)
+	for i, h := range c.sortedSet {
+		if c.hosts[h] != host {
+			continue
+		}
+		for j := 1; j < n; j++ {
+			next := c.hosts[c.sortedSet[(i+j)%n]]
+			if next != host {
+				successors[Range{Start: c.sortedSet[(i+n-1)%n], End: h}] = next
+				break
+			}
+		}
+	}
+	return successors
+}
+
+// HostRanges returns the contiguous ranges owned by each host in the ring
+// order. Adjacent virtual nodes of the same host are merged into one range.
+func (c *Consistent) HostRanges() map[string][]Range {
+	c.RLock()
+	defer c.RUnlock()
+
+	ranges := map[string][]Range{}
+	n := len(c.sortedSet)
+	if n == 0 {
+		return ranges
+	}
+
+	// start from the first virtual node whose predecessor belongs to another
+	// host, so that a range wrapping around the ring is not split.
+	first := 0
+	for first < n && c.hosts[c.sortedSet[(first+n-1)%n]] == c.hosts[c.sortedSet[first]] {
+		first++
+	}
+	if first == n {
+		h := c.sortedSet[n-1]
+		ranges[c.hosts[h]] = []Range{{Start: h, End: h}}
+		return ranges
+	}
+
+	for i := 0; i < n; {
+		idx := (first + i) % n
+		host := c.hosts[c.sortedSet[idx]]
+		r := Range{Start: c.sortedSet[(idx+n-1)%n]}
+		for i < n && c.hosts[c.sortedSet[(first+i)%n]] == host {
+			r.End = c.sortedSet[(first+i)%n]
+			i++
+		}
+		ranges[host] = append(ranges[host], r)
+	}
+	return ranges
+}
+
+// Coverage returns the share of the hash space owned by each host in the ring.
+// A virtual node owns the keys hashing after its predecessor up to its own hash.
+// The shares of all hosts add up to one; hosts without virtual nodes own zero.
+func (c *Consistent) Coverage() map[string]float64 {
+	c.RLock()
+	defer c.RUnlock()
+
+	coverage := make(map[string]float64, len(c.loadMap))
+	for k := range c.loadMap {
+		coverage[k] = 0
+	}
+
+	n := len(c.sortedSet)
+	if n == 1 {
+		coverage[c.hosts[c.sortedSet[0]]] = 1
+		return coverage
+	}
+	for i, h := range c.sortedSet {
+		// the subtraction wraps around for the first virtual node.
+		span := h - c.sortedSet[(i+n-1)%n]
+		coverage[c.hosts[h]] += float64(span) / math.MaxUint64
+	}
+	return coverage
+}
+
+// Ring returns a copy of the virtual nodes of the ring sorted by hash
+func (c *Consistent) Ring() []RingPoint {
+	c.RLock()
+	defer c.RUnlock()
+
+	points := make([]RingPoint, len(c.sortedSet))
+	for i, h := range c.sortedSet {
+		points[i] = RingPoint{Hash: h, Host: c.hosts[h]}
+	}
+	return points
+}
+
+// ReassignedFraction returns the fraction of the hash space whose owner
+// differs between the rings before and after, given as RingPoints sorted by
+// Hash such as returned by Ring. The whole hash space of an empty ring is
+// unowned, so that creating or deleting a ring reassigns all of it.
+func ReassignedFraction(before, after []RingPoint) float64 {
+	switch {
+	case len(before) == 0 && len(after) == 0:
+		return 0
+	case len(before) == 0 || len(after) == 0:
+		return 1
+	}
+
+	hashes := make([]uint64, 0, len(before)+len(after))
+	for _, p := range before {
+		hashes = append(hashes, p.Hash)
+	}
+	for _, p := range after {
+		hashes = append(hashes, p.Hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+
+	// the owners are the same for all hashes of the range (prev, h], so each
+	// range is compared by the owners of h.
+	reassigned := 0.0
+	prev := hashes[len(hashes)-1]
+	for i, h := range hashes {
+		if i > 0 && h == prev {
+			continue
+		}
+		if ringOwner(before, h) != ringOwner(after, h) {
+			if h == prev {
+				// a single distinct hash owns the whole ring.
+				return 1
+			}
+			reassigned += float64(h - prev)
+		}
+		prev = h
+	}
+	return reassigned / math.Exp2(64)
+}
+
+// ringOwner returns the host owning the hash in the sorted points, or the
+// empty string if there are no points.
+func ringOwner(points []RingPoint, hash uint64) string {
+	if len(points) == 0 {
+		return ""
+	}
+	i := sort.Search(len(points), func(i int) bool { return points[i].Hash >= hash })
+	if i == len(points) {
+		i = 0
+	}
+	return points[i].Host
+}
+
 // Hosts return the list of hosts in the ring
 func (c *Consistent) Hosts() (hosts []string) {
 	c.RLock()
@@ -255,6 +573,39 @@ func (c *Consistent) Hosts() (hosts []string) {
 	return hosts
 }
 
+// HostMap returns the app id of each host in the ring
+func (c *Consistent) HostMap() map[string]string {
+	c.RLock()
+	defer c.RUnlock()
+	hosts := make(map[string]string, len(c.loadMap))
+	for k, v := range c.loadMap {
+		hosts[k] = v.AppID
+	}
+	return hosts
+}
+
+// HostCount returns the number of hosts in the ring
+func (c *Consistent) HostCount() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.loadMap)
+}
+
+// Contains returns true if the host is in the ring
+func (c *Consistent) Contains(host string) bool {
+	c.RLock()
+	defer c.RUnlock()
+	_, ok := c.loadMap[host]
+	return ok
+}
+
+// VirtualNodeCount returns the number of virtual nodes in the ring
+func (c *Consistent) VirtualNodeCount() int {
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.sortedSet)
+}
+
 // GetLoads returns the loads of all the hosts
 func (c *Consistent) GetLoads() map[string]int64 {
 	loads := map[string]int64{}
@@ -267,7 +618,7 @@ func (c *Consistent) GetLoads() map[string]int64 {
 
 // MaxLoad returns the maximum load of the single host
 // which is:
-// (total_load/number_of_hosts)*1.25
+// (total_load/number_of_hosts)*load_factor
 // total_load = is the total number of active requests served by hosts
 // for more info:
 // https://research.googleblog.com/2017/04/consistent-hashing-with-bounded-loads.html
@@ -280,7 +631,7 @@ func (c *Consistent) MaxLoad() int64 {
 	if avgLoadPerNode == 0 {
 		avgLoadPerNode = 1
 	}
-	avgLoadPerNode = math.Ceil(avgLoadPerNode * 1.25)
+	avgLoadPerNode = math.Ceil(avgLoadPerNode * c.loadFactor)
 	return int64(avgLoadPerNode)
 }
 
@@ -295,7 +646,7 @@ func (c *Consistent) loadOK(host string) bool {
 	if avgLoadPerNode == 0 {
 		avgLoadPerNode = 1
 	}
-	avgLoadPerNode = math.Ceil(avgLoadPerNode * 1.25)
+	avgLoadPerNode = math.Ceil(avgLoadPerNode * c.loadFactor)
 
 	bhost, ok := c.loadMap[host]
 	if !ok {
@@ -330,10 +681,28 @@ func (c *Consistent) delSlice(val uint64) {
 }
 
 func (c *Consistent) hash(key string) uint64 {
-	out := blake2b.Sum512([]byte(key))
+	return c.hashFunc([]byte(key))
+}
+
+func defaultHash(key []byte) uint64 {
+	out := blake2b.Sum512(key)
 	return binary.LittleEndian.Uint64(out[:])
 }
 
+// vnodeCount returns the number of virtual nodes placed for a host with the given weight.
+// A ring without its own replication factor reads the package one lazily, so
+// rings built before SetReplicationFactor still get the configured vnodes.
+func (c *Consistent) vnodeCount(weight int) int {
+	replicas := c.replicas
+	if replicas <= 0 {
+		replicas = replicationFactor
+	}
+	if weight <= 1 {
+		return replicas
+	}
+	return replicas * weight
+}
+
 // SetReplicationFactor sets the replication factor for actor placement on vnodes
 func SetReplicationFactor(factor int) {
 	replicationFactor = factor
@@ -6,9 +6,12 @@
 package hashing
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"testing"
 
+	blake2b "github.com/minio/blake2b-simd"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -63,3 +66,567 @@ func TestSetReplicationFactor(t *testing.T) {
 
 	assert.Equal(t, f, replicationFactor)
 }
+
+func TestReplicationFactorAfterConstruction(t *testing.T) {
+	t.Run("ring built before the factor is set", func(t *testing.T) {
+		// arrange
+		SetReplicationFactor(0)
+		defer SetReplicationFactor(10)
+		h := NewConsistentHash()
+		existing := NewFromExisting(map[uint64]string{}, []uint64{}, map[string]*Host{})
+
+		// act
+		SetReplicationFactor(10)
+		h.Add("node1", "node1", 1)
+		existing.Add("node1", "node1", 1)
+
+		// assert
+		assert.Equal(t, 10, len(h.sortedSet))
+		assert.Equal(t, 10, len(existing.sortedSet))
+		host, err := h.Get("actor1")
+		assert.NoError(t, err)
+		assert.Equal(t, "node1", host)
+	})
+
+	t.Run("explicit factor wins", func(t *testing.T) {
+		SetReplicationFactor(10)
+		h := NewConsistentHash(WithReplicationFactor(3))
+		h.Add("node1", "node1", 1)
+
+		assert.Equal(t, 3, len(h.sortedSet))
+	})
+
+	t.Run("reset drops the explicit factor", func(t *testing.T) {
+		SetReplicationFactor(10)
+		h := NewConsistentHash(WithReplicationFactor(3))
+		h.Reset()
+		h.Add("node1", "node1", 1)
+
+		assert.Equal(t, 10, len(h.sortedSet))
+	})
+}
+
+func TestAddWithWeight(t *testing.T) {
+	SetReplicationFactor(10)
+
+	t.Run("zero weight uses default vnodes", func(t *testing.T) {
+		h := NewConsistentHash()
+		h.AddWithWeight("node1", "node1", 1, 0)
+		h.Add("node2", "node2", 1)
+
+		assert.Equal(t, 20, len(h.sortedSet))
+	})
+
+	t.Run("weight scales vnodes", func(t *testing.T) {
+		h := NewConsistentHash()
+		h.AddWithWeight("node1", "node1", 1, 4)
+		h.AddWithWeight("node2", "node2", 1, 1)
+
+		assert.Equal(t, 50, len(h.sortedSet))
+		assert.Equal(t, 50, len(h.hosts))
+	})
+
+	t.Run("remove weighted host clears all vnodes", func(t *testing.T) {
+		h := NewConsistentHash()
+		h.AddWithWeight("node1", "node1", 1, 4)
+		h.AddWithWeight("node2", "node2", 1, 2)
+
+		h.Remove("node1")
+
+		assert.Equal(t, 20, len(h.sortedSet))
+		for _, v := range h.hosts {
+			assert.Equal(t, "node2", v)
+		}
+	})
+}
+
+func TestSuccessorHosts(t *testing.T) {
+	SetReplicationFactor(100)
+
+	h := NewConsistentHash()
+	for _, n := range nodes {
+		h.Add(n, n, 1)
+	}
+
+	t.Run("successors take over removed host keys", func(t *testing.T) {
+		keys := map[string]string{}
+		for i := 0; i < 1000; i++ {
+			k := fmt.Sprint(i)
+			owner, _ := h.Get(k)
+			keys[k] = owner
+		}
+
+		successors := h.SuccessorHosts("node3")
+		assert.NotContains(t, successors, "node3")
+
+		c := NewConsistentHash()
+		for _, n := range nodes {
+			if n != "node3" {
+				c.Add(n, n, 1)
+			}
+		}
+		for k, owner := range keys {
+			if owner != "node3" {
+				continue
+			}
+			newOwner, _ := c.Get(k)
+			assert.Contains(t, successors, newOwner)
+		}
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		assert.Empty(t, h.SuccessorHosts("node100"))
+	})
+
+	t.Run("single host", func(t *testing.T) {
+		c := NewConsistentHash()
+		c.Add("node1", "node1", 1)
+		assert.Empty(t, c.SuccessorHosts("node1"))
+	})
+}
+
+func TestCoverage(t *testing.T) {
+	SetReplicationFactor(100)
+
+	t.Run("shares add up to one", func(t *testing.T) {
+		h := NewConsistentHash()
+		for _, n := range nodes {
+			h.Add(n, n, 1)
+		}
+
+		coverage := h.Coverage()
+		assert.Equal(t, len(nodes), len(coverage))
+
+		total := 0.0
+		for _, c := range coverage {
+			assert.True(t, c > 0)
+			total += c
+		}
+		assert.InDelta(t, 1.0, total, 1e-9)
+	})
+
+	t.Run("heavier host owns more", func(t *testing.T) {
+		h := NewConsistentHash()
+		h.AddWithWeight("node1", "node1", 1, 4)
+		h.Add("node2", "node2", 1)
+
+		coverage := h.Coverage()
+		assert.True(t, coverage["node1"] > coverage["node2"])
+	})
+
+	t.Run("single virtual node owns everything", func(t *testing.T) {
+		SetReplicationFactor(1)
+		defer SetReplicationFactor(100)
+
+		h := NewConsistentHash()
+		h.Add("node1", "node1", 1)
+		assert.Equal(t, map[string]float64{"node1": 1}, h.Coverage())
+	})
+
+	t.Run("empty ring", func(t *testing.T) {
+		assert.Empty(t, NewConsistentHash().Coverage())
+	})
+}
+
+func TestRing(t *testing.T) {
+	SetReplicationFactor(10)
+
+	h := NewConsistentHash()
+	for _, n := range nodes {
+		h.Add(n, n, 1)
+	}
+
+	t.Run("sorted by hash", func(t *testing.T) {
+		ring := h.Ring()
+		assert.Equal(t, 10*len(nodes), len(ring))
+		for i := 1; i < len(ring); i++ {
+			assert.True(t, ring[i-1].Hash < ring[i].Hash)
+		}
+		for _, p := range ring {
+			assert.Contains(t, nodes, p.Host)
+		}
+	})
+
+	t.Run("returns a copy", func(t *testing.T) {
+		ring := h.Ring()
+		ring[0].Host = "node100"
+		assert.NotEqual(t, "node100", h.Ring()[0].Host)
+	})
+
+	t.Run("empty ring", func(t *testing.T) {
+		assert.Empty(t, NewConsistentHash().Ring())
+	})
+}
+
+func TestReassignedFraction(t *testing.T) {
+	quarter := uint64(1) << 62
+	half := uint64(1) << 63
+
+	t.Run("empty rings", func(t *testing.T) {
+		assert.Equal(t, 0.0, ReassignedFraction(nil, nil))
+		assert.Equal(t, 1.0, ReassignedFraction(nil, []RingPoint{{Hash: quarter, Host: "a"}}))
+		assert.Equal(t, 1.0, ReassignedFraction([]RingPoint{{Hash: quarter, Host: "a"}}, nil))
+	})
+
+	t.Run("owner of a range changes", func(t *testing.T) {
+		before := []RingPoint{{Hash: quarter, Host: "a"}, {Hash: half, Host: "b"}}
+		after := []RingPoint{{Hash: quarter, Host: "a"}, {Hash: half, Host: "a"}}
+
+		assert.Equal(t, 0.0, ReassignedFraction(before, before))
+		assert.Equal(t, 0.25, ReassignedFraction(before, after))
+		assert.Equal(t, 0.25, ReassignedFraction(after, before))
+	})
+
+	t.Run("wrapping range changes", func(t *testing.T) {
+		before := []RingPoint{{Hash: quarter, Host: "a"}, {Hash: half, Host: "b"}}
+		after := []RingPoint{{Hash: quarter, Host: "b"}, {Hash: half, Host: "b"}}
+
+		assert.Equal(t, 0.75, ReassignedFraction(before, after))
+	})
+
+	t.Run("adding a host", func(t *testing.T) {
+		SetReplicationFactor(100)
+		h := NewConsistentHash()
+		h.Add("node1", "app1", 1)
+		h.Add("node2", "app2", 1)
+		before := h.Ring()
+		h.Add("node3", "app3", 1)
+
+		fraction := ReassignedFraction(before, h.Ring())
+		assert.InDelta(t, h.Coverage()["node3"], fraction, 1e-9)
+	})
+}
+
+func TestRangeSuccessors(t *testing.T) {
+	SetReplicationFactor(100)
+
+	h := NewConsistentHash()
+	for _, n := range nodes {
+		h.Add(n, n, 1)
+	}
+
+	t.Run("successors own the removed ranges", func(t *testing.T) {
+		successors := h.RangeSuccessors("node3")
+		assert.Equal(t, 100, len(successors))
+
+		c := NewConsistentHash()
+		for _, n := range nodes {
+			if n != "node3" {
+				c.Add(n, n, 1)
+			}
+		}
+		for r, next := range successors {
+			assert.NotEqual(t, "node3", next)
+			assert.Equal(t, next, c.hosts[c.sortedSet[c.search(r.End)]])
+		}
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		assert.Empty(t, h.RangeSuccessors("node100"))
+	})
+}
+
+func TestUpdateAppID(t *testing.T) {
+	SetReplicationFactor(10)
+
+	h := NewConsistentHash()
+	h.Add("node1", "app1", 1)
+	ring := h.Ring()
+
+	assert.True(t, h.UpdateAppID("node1", "app2"))
+	host, err := h.GetHost("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "app2", host.AppID)
+	assert.Equal(t, ring, h.Ring())
+
+	assert.False(t, h.UpdateAppID("node2", "app2"))
+}
+
+func TestContains(t *testing.T) {
+	SetReplicationFactor(10)
+
+	h := NewConsistentHash()
+	h.Add("node1", "app1", 1)
+	h.Add("node2", "app2", 1)
+	h.Remove("node2")
+
+	assert.True(t, h.Contains("node1"))
+	assert.False(t, h.Contains("node2"))
+	assert.False(t, h.Contains("node3"))
+}
+
+func TestHostMap(t *testing.T) {
+	SetReplicationFactor(10)
+
+	h := NewConsistentHash()
+	h.Add("node1", "app1", 1)
+	h.Add("node2", "app2", 1)
+	h.Add("node3", "app3", 1)
+	h.Remove("node3")
+	h.UpdateAppID("node2", "app2_v2")
+
+	assert.Equal(t, map[string]string{"node1": "app1", "node2": "app2_v2"}, h.HostMap())
+	assert.Empty(t, NewConsistentHash().HostMap())
+}
+
+func TestWithReplicationFactor(t *testing.T) {
+	SetReplicationFactor(10)
+
+	t.Run("ring replication factor", func(t *testing.T) {
+		h := NewConsistentHash(WithReplicationFactor(3))
+		h.Add("node1", "node1", 1)
+		h.AddWithWeight("node2", "node2", 1, 2)
+		assert.Equal(t, 9, len(h.Ring()))
+
+		h.Remove("node2")
+		assert.Equal(t, 3, len(h.Ring()))
+	})
+
+	t.Run("zero keeps the default", func(t *testing.T) {
+		h := NewConsistentHash(WithReplicationFactor(0))
+		h.Add("node1", "node1", 1)
+		assert.Equal(t, 10, len(h.Ring()))
+	})
+
+	t.Run("virtual node count", func(t *testing.T) {
+		h := NewConsistentHash(WithReplicationFactor(3))
+		h.Add("node1", "node1", 1)
+		h.AddWithWeight("node2", "node2", 1, 4)
+		assert.Equal(t, 15, h.VirtualNodeCount())
+	})
+
+	t.Run("reset restores the default", func(t *testing.T) {
+		h := NewConsistentHash(WithReplicationFactor(3))
+		h.Reset()
+		h.Add("node1", "node1", 1)
+		assert.Equal(t, 10, len(h.Ring()))
+	})
+}
+
+func TestGetN(t *testing.T) {
+	SetReplicationFactor(10)
+
+	h := NewConsistentHash()
+	for _, n := range nodes {
+		h.Add(n, n, 1)
+	}
+
+	t.Run("distinct hosts starting with the owner", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			k := fmt.Sprint(i)
+			owner, _ := h.Get(k)
+			hosts := h.GetN(k, 3)
+			assert.Equal(t, 3, len(hosts))
+			assert.Equal(t, owner, hosts[0])
+			assert.NotEqual(t, hosts[0], hosts[1])
+			assert.NotEqual(t, hosts[1], hosts[2])
+			assert.NotEqual(t, hosts[0], hosts[2])
+		}
+	})
+
+	t.Run("fewer hosts than n", func(t *testing.T) {
+		hosts := h.GetN("key1", 10)
+		assert.ElementsMatch(t, nodes, hosts)
+	})
+
+	t.Run("empty ring", func(t *testing.T) {
+		assert.Empty(t, NewConsistentHash().GetN("key1", 3))
+		assert.Empty(t, h.GetN("key1", 0))
+	})
+}
+
+func TestHostRanges(t *testing.T) {
+	SetReplicationFactor(10)
+
+	t.Run("ranges cover the ring", func(t *testing.T) {
+		h := NewConsistentHash()
+		for _, n := range nodes {
+			h.Add(n, n, 1)
+		}
+
+		ranges := h.HostRanges()
+		assert.Equal(t, len(nodes), len(ranges))
+
+		// every range ends at a virtual node of its host.
+		ends := map[uint64]string{}
+		for host, rs := range ranges {
+			for _, r := range rs {
+				assert.Equal(t, host, h.hosts[r.End])
+				ends[r.End] = host
+			}
+		}
+
+		// the ranges chain around the ring.
+		starts := map[uint64]struct{}{}
+		for _, rs := range ranges {
+			for _, r := range rs {
+				starts[r.Start] = struct{}{}
+			}
+		}
+		assert.Equal(t, len(ends), len(starts))
+		for s := range starts {
+			assert.Contains(t, ends, s)
+		}
+	})
+
+	t.Run("adjacent virtual nodes are merged", func(t *testing.T) {
+		h := NewConsistentHash(WithHashFunc(func(key []byte) uint64 {
+			return map[string]uint64{
+				"node10": 10, "node11": 20,
+				"node20": 30, "node21": 40,
+			}[string(key)]
+		}), WithReplicationFactor(2))
+		h.Add("node1", "node1", 1)
+		h.Add("node2", "node2", 1)
+
+		assert.Equal(t, map[string][]Range{
+			"node1": {{Start: 40, End: 20}},
+			"node2": {{Start: 20, End: 40}},
+		}, h.HostRanges())
+	})
+
+	t.Run("single host owns the whole ring", func(t *testing.T) {
+		h := NewConsistentHash()
+		h.Add("node1", "node1", 1)
+
+		ranges := h.HostRanges()
+		assert.Equal(t, 1, len(ranges["node1"]))
+		assert.Equal(t, ranges["node1"][0].Start, ranges["node1"][0].End)
+	})
+
+	t.Run("empty ring", func(t *testing.T) {
+		assert.Empty(t, NewConsistentHash().HostRanges())
+	})
+}
+
+func TestWithLoadFactor(t *testing.T) {
+	SetReplicationFactor(10)
+
+	newRing := func(opts ...Option) *Consistent {
+		h := NewConsistentHash(opts...)
+		for _, n := range nodes {
+			h.Add(n, n, 1)
+		}
+		return h
+	}
+
+	t.Run("default load factor", func(t *testing.T) {
+		h := newRing()
+		h.UpdateLoad("node1", 100)
+		assert.Equal(t, int64(25), h.MaxLoad())
+	})
+
+	t.Run("custom load factor", func(t *testing.T) {
+		h := newRing(WithLoadFactor(2))
+		h.UpdateLoad("node1", 100)
+		assert.Equal(t, int64(40), h.MaxLoad())
+	})
+
+	t.Run("factor not greater than one keeps the default", func(t *testing.T) {
+		for _, factor := range []float64{1, 0.5, 0, -1} {
+			h := newRing(WithLoadFactor(factor))
+			h.UpdateLoad("node1", 100)
+			assert.Equal(t, int64(25), h.MaxLoad())
+		}
+	})
+
+	t.Run("every host over the bound falls back to the owner", func(t *testing.T) {
+		h := newRing()
+		h.loadFactor = 1
+		for _, n := range nodes {
+			h.UpdateLoad(n, 1)
+		}
+		owner, _ := h.Get("key1")
+
+		// act
+		host, err := h.GetLeast("key1")
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, owner, host)
+	})
+
+	t.Run("overloaded host spills to the next host", func(t *testing.T) {
+		h := newRing(WithLoadFactor(1.5))
+		owner, _ := h.Get("key1")
+		h.UpdateLoad(owner, 100)
+
+		host, err := h.GetLeast("key1")
+		assert.NoError(t, err)
+		assert.NotEqual(t, owner, host)
+	})
+}
+
+func TestWithHashFunc(t *testing.T) {
+	SetReplicationFactor(10)
+
+	t.Run("default hash is unchanged", func(t *testing.T) {
+		h := NewConsistentHash(WithHashFunc(nil))
+		h.Add("node1", "node1", 1)
+
+		out := blake2b.Sum512([]byte("node10"))
+		_, ok := h.hosts[binary.LittleEndian.Uint64(out[:])]
+		assert.True(t, ok)
+	})
+
+	t.Run("custom hash", func(t *testing.T) {
+		calls := 0
+		h := NewConsistentHash(WithHashFunc(func(key []byte) uint64 {
+			calls++
+			f := fnv.New64a()
+			f.Write(key)
+			return f.Sum64()
+		}))
+		h.Add("node1", "node1", 1)
+		h.Add("node2", "node2", 1)
+
+		host, err := h.Get("key")
+		assert.NoError(t, err)
+		assert.Contains(t, []string{"node1", "node2"}, host)
+		assert.Equal(t, 21, calls)
+	})
+}
+
+func TestReset(t *testing.T) {
+	SetReplicationFactor(10)
+
+	h := NewConsistentHash(WithHashFunc(func(key []byte) uint64 { return 1 }))
+	h.Add("node1", "node1", 1)
+	h.UpdateLoad("node1", 5)
+
+	// act
+	h.Reset()
+
+	// assert
+	hosts, sortedSet, loadMap, totalLoad := h.GetInternals()
+	assert.Empty(t, hosts)
+	assert.Empty(t, sortedSet)
+	assert.Empty(t, loadMap)
+	assert.Equal(t, int64(0), totalLoad)
+
+	h.Add("node2", "node2", 1)
+	assert.Equal(t, 10, len(h.hosts), "default hash function must be restored")
+}
+
+func BenchmarkNewConsistentHash(b *testing.B) {
+	SetReplicationFactor(100)
+
+	b.Run("new", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			h := NewConsistentHash()
+			h.Add("node1", "node1", 1)
+			h.Remove("node1")
+		}
+	})
+
+	b.Run("reset", func(b *testing.B) {
+		b.ReportAllocs()
+		h := NewConsistentHash()
+		for i := 0; i < b.N; i++ {
+			h.Reset()
+			h.Add("node1", "node1", 1)
+			h.Remove("node1")
+		}
+	})
+}
@@ -145,7 +145,7 @@ func (p *Service) membershipChangeWorker(stopCh chan struct{}) {
 			// Each dapr runtime sends the heartbeat every one second and placement will update UpdatedAt timestamp.
 			// If UpdatedAt is outdated, we can mark the host as faulty node.
 			// This faulty host will be removed from membership in the next dissemination period.
-			m := p.raftNode.FSM().State().Members
+			m := p.raftNode.FSM().State().SnapshotMembers()
 			for _, v := range m {
 				if t.Sub(v.UpdatedAt) <= p.faultyHostDetectDuration {
 					continue
@@ -200,8 +200,18 @@ func (p *Service) processRaftStateCommand(op hostMemberChange) {
 
 		// When placement node first gets the leadership, it needs to wait until runtimes connecting
 		// old leader connects to new leader. The numbers will be eventually consistent.
+		// the generation deferred by the generation window is applied through
+		// raft before the tables are disseminated, so that their version
+		// changes with them on every replica.
+		if p.raftNode.FSM().State().PendingGeneration() {
+			if _, err := p.raftNode.ApplyCommand(raft.TableGenerationFlush, raft.DaprHostMember{}); err != nil {
+				log.Errorf("fail to flush table generation: %v", err)
+				return
+			}
+		}
+
 		streamConns := len(p.streamConns)
-		targetConns := len(p.raftNode.FSM().State().Members)
+		targetConns := p.raftNode.FSM().State().MemberCount()
 		if streamConns == targetConns {
 			log.Debugf(
 				"desseminate tables to memers. memberUpdateCount: %d, streams: %d, targets: %d",
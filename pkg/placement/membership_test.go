@@ -12,6 +12,7 @@ import (
 
 	"github.com/dapr/dapr/pkg/placement/raft"
 	v1pb "github.com/dapr/dapr/pkg/proto/placement/v1"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 )
@@ -208,3 +209,50 @@ func TestPerformTableUpdate(t *testing.T) {
 
 	cleanup()
 }
+
+func TestApplyCommandFrozen(t *testing.T) {
+	// arrange
+	cleanupStates()
+	state := testRaftServer.FSM().State()
+	state.Freeze()
+	defer state.Unfreeze()
+
+	// act
+	updated, err := testRaftServer.ApplyCommand(raft.MemberUpsert, raft.DaprHostMember{
+		Name:     "127.0.0.1:50100",
+		AppID:    "testAppID",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	// assert
+	assert.Equal(t, raft.ErrFrozen, err)
+	assert.False(t, updated)
+	assert.Equal(t, 0, state.MemberCount())
+}
+
+func TestApplyCommandNameCollision(t *testing.T) {
+	// arrange
+	cleanupStates()
+	state := testRaftServer.FSM().State()
+	state.SetNameCollisionWindow(time.Hour)
+	defer state.SetNameCollisionWindow(0)
+	_, err := testRaftServer.ApplyCommand(raft.MemberUpsert, raft.DaprHostMember{
+		Name:     "127.0.0.1:50100",
+		AppID:    "testAppID",
+		Entities: []string{"actorTypeOne"},
+	})
+	assert.NoError(t, err)
+
+	// act
+	updated, err := testRaftServer.ApplyCommand(raft.MemberUpsert, raft.DaprHostMember{
+		Name:     "127.0.0.1:50100",
+		AppID:    "testAppID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	// assert
+	assert.Equal(t, raft.ErrNameCollision, errors.Cause(err))
+	assert.False(t, updated)
+	assert.Equal(t, "testAppID", state.SnapshotMembers()["127.0.0.1:50100"].AppID)
+	cleanupStates()
+}
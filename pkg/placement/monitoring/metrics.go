@@ -31,6 +31,22 @@ var (
 		"placement/replicas_peractortype_total",
 		"The total number of replicas per actor type reported to placement service.",
 		stats.UnitDimensionless)
+	memberUpsertsTotal = stats.Int64(
+		"placement/member_upserts_total",
+		"The total number of member upserts applied to placement state.",
+		stats.UnitDimensionless)
+	memberNoopUpsertsTotal = stats.Int64(
+		"placement/member_noop_upserts_total",
+		"The total number of member upserts which don't change placement state.",
+		stats.UnitDimensionless)
+	memberRemovalsTotal = stats.Int64(
+		"placement/member_removals_total",
+		"The total number of members removed from placement state.",
+		stats.UnitDimensionless)
+	tableGenerationsTotal = stats.Int64(
+		"placement/table_generations_total",
+		"The total number of hashing table generation increments.",
+		stats.UnitDimensionless)
 
 	noKeys       = []tag.Key{}
 	actorTypeKey = tag.MustNewKey("actor_type")
@@ -57,6 +73,29 @@ func RecordPerActorTypeReplicasCount(actorType, hostName string) {
 	stats.RecordWithTags(context.Background(), diag_utils.WithTags(actorTypeKey, actorType, hostNameKey, hostName), replicasPerActorTypeTotal.M(1))
 }
 
+// MutationRecorder records membership mutations of placement state.
+type MutationRecorder struct{}
+
+// IncUpserts records a member upsert
+func (MutationRecorder) IncUpserts() {
+	stats.Record(context.Background(), memberUpsertsTotal.M(1))
+}
+
+// IncNoopUpserts records a member upsert without changes
+func (MutationRecorder) IncNoopUpserts() {
+	stats.Record(context.Background(), memberNoopUpsertsTotal.M(1))
+}
+
+// IncRemovals records a member removal
+func (MutationRecorder) IncRemovals() {
+	stats.Record(context.Background(), memberRemovalsTotal.M(1))
+}
+
+// IncTableGenerations records a hashing table generation increment
+func (MutationRecorder) IncTableGenerations() {
+	stats.Record(context.Background(), tableGenerationsTotal.M(1))
+}
+
 // InitMetrics initialize the placement service metrics
 func InitMetrics() error {
 	err := view.Register(
@@ -64,6 +103,10 @@ func InitMetrics() error {
 		diag_utils.NewMeasureView(actorTypesTotal, noKeys, view.LastValue()),
 		diag_utils.NewMeasureView(nonActorHostsTotal, noKeys, view.LastValue()),
 		diag_utils.NewMeasureView(replicasPerActorTypeTotal, []tag.Key{actorTypeKey, hostNameKey}, view.Count()),
+		diag_utils.NewMeasureView(memberUpsertsTotal, noKeys, view.Count()),
+		diag_utils.NewMeasureView(memberNoopUpsertsTotal, noKeys, view.Count()),
+		diag_utils.NewMeasureView(memberRemovalsTotal, noKeys, view.Count()),
+		diag_utils.NewMeasureView(tableGenerationsTotal, noKeys, view.Count()),
 	)
 
 	return err
@@ -0,0 +1,124 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+)
+
+// entityFilterHashes is the number of bits set per key in entityFilter.
+const entityFilterHashes = 3
+
+// entityFilter is a bloom filter over the hashing table keys. mayContain
+// never returns false for an added key, so that a lookup of a key which is
+// not in the filter can skip the state lock. Its bits are accessed atomically,
+// so that keys can be added while it is read.
+type entityFilter struct {
+	bits []uint64
+}
+
+// newEntityFilter returns an empty filter with at least the given number of
+// bits.
+func newEntityFilter(bits int) *entityFilter {
+	return &entityFilter{bits: make([]uint64, (bits+63)/64)}
+}
+
+func (f *entityFilter) add(key string) {
+	for _, i := range f.positions(key) {
+		word, mask := &f.bits[i/64], uint64(1)<<(i%64)
+		for {
+			old := atomic.LoadUint64(word)
+			if old&mask != 0 || atomic.CompareAndSwapUint64(word, old, old|mask) {
+				break
+			}
+		}
+	}
+}
+
+// mayContain returns false if the key is definitely not added.
+func (f *entityFilter) mayContain(key string) bool {
+	for _, i := range f.positions(key) {
+		if atomic.LoadUint64(&f.bits[i/64])&(uint64(1)<<(i%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// positions returns the bit positions of the key, derived from the two halves
+// of its FNV-1a hash.
+func (f *entityFilter) positions(key string) [entityFilterHashes]uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	h1, h2 := sum&0xffffffff, sum>>32
+	n := uint64(len(f.bits)) * 64
+
+	var positions [entityFilterHashes]uint64
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % n
+	}
+	return positions
+}
+
+// SetEntityFilter enables the bloom filter of the given number of bits over
+// the hashing table keys, the aliases and the pinned Actor Types, so that
+// ResolveActorHost and ResolveBatch return without taking the state lock for
+// an Actor Type which is definitely unknown. Known Actor Types may share the
+// bits of an unknown one, which then takes the usual lookup. Removed keys are
+// dropped from the filter when TableGeneration is next increased. Zero
+// disables the filter, which is the default.
+func (s *DaprHostMemberState) SetEntityFilter(bits int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.entityFilterBits = bits
+	s.rebuildEntityFilter()
+}
+
+// loadEntityFilter returns the current filter or nil if it is disabled. It
+// doesn't need the lock.
+func (s *DaprHostMemberState) loadEntityFilter() *entityFilter {
+	f, _ := s.entityFilter.Load().(*entityFilter)
+	return f
+}
+
+// mayHaveEntity returns false if the key definitely has no hashing table,
+// alias or pin, without taking the lock.
+func (s *DaprHostMemberState) mayHaveEntity(key string) bool {
+	f := s.loadEntityFilter()
+	return f == nil || f.mayContain(key)
+}
+
+// addToEntityFilter adds the key to the filter, if it is enabled. The caller
+// must hold the write lock.
+func (s *DaprHostMemberState) addToEntityFilter(key string) {
+	if f := s.loadEntityFilter(); f != nil {
+		f.add(key)
+	}
+}
+
+// rebuildEntityFilter replaces the filter with a new one of the current keys,
+// dropping the removed keys. The caller must hold the write lock.
+func (s *DaprHostMemberState) rebuildEntityFilter() {
+	if s.entityFilterBits <= 0 {
+		s.entityFilter.Store((*entityFilter)(nil))
+		return
+	}
+
+	f := newEntityFilter(s.entityFilterBits)
+	for key := range s.hashingTableMap {
+		f.add(key)
+	}
+	for alias := range s.Aliases {
+		f.add(alias)
+	}
+	for entity := range s.Pins {
+		f.add(entity)
+	}
+	s.entityFilter.Store(f)
+}
@@ -0,0 +1,150 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityFilter(t *testing.T) {
+	// arrange
+	f := newEntityFilter(1024)
+	for i := 0; i < 50; i++ {
+		f.add(fmt.Sprintf("actorType%d", i))
+	}
+
+	t.Run("added keys", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			assert.True(t, f.mayContain(fmt.Sprintf("actorType%d", i)))
+		}
+	})
+
+	t.Run("unknown keys are mostly rejected", func(t *testing.T) {
+		// act
+		falsePositives := 0
+		for i := 0; i < 1000; i++ {
+			if f.mayContain(fmt.Sprintf("unknown%d", i)) {
+				falsePositives++
+			}
+		}
+
+		// assert
+		assert.True(t, falsePositives < 100)
+	})
+}
+
+func TestSetEntityFilter(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:9090",
+		AppID:    "Coordinator",
+		Entities: []string{"actorTypePinned"},
+	})
+	s.SetEntityFilter(1024)
+
+	t.Run("known entity resolves", func(t *testing.T) {
+		// act
+		host, _, ok := s.ResolveActorHost("actorTypeOne", "actor1")
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, "127.0.0.1:8080", host)
+		assert.True(t, s.mayHaveEntity("actorTypeOne"))
+	})
+
+	t.Run("new entity is added to the filter", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeTwo"},
+		})
+		_, _, ok := s.ResolveActorHost("actorTypeTwo", "actor1")
+
+		// assert
+		assert.True(t, ok)
+	})
+
+	t.Run("aliases and pins are in the filter", func(t *testing.T) {
+		// act
+		assert.NoError(t, s.AddAlias("actorTypeOld", "actorTypeOne"))
+		assert.NoError(t, s.AddPin("actorTypePinned", "singleton", "127.0.0.1:9090"))
+		_, _, aliasOK := s.ResolveActorHost("actorTypeOld", "actor1")
+		host, _, pinOK := s.ResolveActorHost("actorTypePinned", "singleton")
+
+		// assert
+		assert.True(t, aliasOK)
+		assert.True(t, pinOK)
+		assert.Equal(t, "127.0.0.1:9090", host)
+	})
+
+	t.Run("removed entity is dropped on the next generation", func(t *testing.T) {
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+		hosts, ok := s.ResolveBatch("actorTypeTwo", []string{"actor1"})
+
+		// assert
+		assert.False(t, ok)
+		assert.Nil(t, hosts)
+		assert.False(t, s.loadEntityFilter().mayContain("actorTypeTwo"))
+	})
+
+	t.Run("disabled filter", func(t *testing.T) {
+		// act
+		s.SetEntityFilter(0)
+
+		// assert
+		assert.Nil(t, s.loadEntityFilter())
+		assert.True(t, s.mayHaveEntity("actorTypeUnknown"))
+	})
+}
+
+func TestEntityFilterConcurrentLookups(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.SetEntityFilter(1024)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			s.upsertMember(&DaprHostMember{
+				Name:     fmt.Sprintf("127.0.0.1:%d", 8000+i),
+				AppID:    "FakeID",
+				Entities: []string{fmt.Sprintf("actorType%d", i)},
+			})
+		}
+	}()
+
+	// act
+	for i := 0; i < 100; i++ {
+		s.ResolveActorHost(fmt.Sprintf("actorType%d", i), "actor1")
+	}
+	wg.Wait()
+
+	// assert
+	for i := 0; i < 100; i++ {
+		_, _, ok := s.ResolveActorHost(fmt.Sprintf("actorType%d", i), "actor1")
+		assert.True(t, ok)
+	}
+}
@@ -0,0 +1,118 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"time"
+)
+
+// defaultEventLogSize is the default number of membership events retained.
+const defaultEventLogSize = 256
+
+// MembershipEventOp is the kind of a membership event.
+type MembershipEventOp string
+
+const (
+	// MembershipEventAdd is recorded when a member is added or changed.
+	MembershipEventAdd MembershipEventOp = "add"
+	// MembershipEventRemove is recorded when a member is removed.
+	MembershipEventRemove MembershipEventOp = "remove"
+	// MembershipEventDrain is recorded when a member is drained.
+	MembershipEventDrain MembershipEventOp = "drain"
+	// MembershipEventUndrain is recorded when a drained member is undrained.
+	MembershipEventUndrain MembershipEventOp = "undrain"
+)
+
+// MembershipEvent is a membership change retained for post-mortem analysis.
+type MembershipEvent struct {
+	Timestamp time.Time
+	Op        MembershipEventOp
+	Name      string
+	AppID     string
+}
+
+// eventLog is a bounded buffer of membership events which evicts the oldest
+// event when it is full. It is runtime state and not persisted in snapshots.
+type eventLog struct {
+	events []MembershipEvent
+	// start is the index of the oldest event.
+	start int
+	count int
+}
+
+func newEventLog(size int) *eventLog {
+	return &eventLog{events: make([]MembershipEvent, size)}
+}
+
+func (l *eventLog) add(e MembershipEvent) {
+	if len(l.events) == 0 {
+		return
+	}
+	if l.count < len(l.events) {
+		l.events[(l.start+l.count)%len(l.events)] = e
+		l.count++
+		return
+	}
+	l.events[l.start] = e
+	l.start = (l.start + 1) % len(l.events)
+}
+
+// list returns the events from the oldest to the newest.
+func (l *eventLog) list() []MembershipEvent {
+	events := make([]MembershipEvent, l.count)
+	for i := 0; i < l.count; i++ {
+		events[i] = l.events[(l.start+i)%len(l.events)]
+	}
+	return events
+}
+
+// SetEventLogSize sets the number of membership events retained by
+// RecentEvents. The most recent events are kept. Zero disables the event log.
+func (s *DaprHostMemberState) SetEventLogSize(size int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if size < 0 {
+		size = 0
+	}
+
+	var events []MembershipEvent
+	if s.events != nil {
+		events = s.events.list()
+	}
+	if len(events) > size {
+		events = events[len(events)-size:]
+	}
+
+	s.events = newEventLog(size)
+	for _, e := range events {
+		s.events.add(e)
+	}
+}
+
+// RecentEvents returns the retained membership events from the oldest to
+// the newest.
+func (s *DaprHostMemberState) RecentEvents() []MembershipEvent {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.events == nil {
+		return []MembershipEvent{}
+	}
+	return s.events.list()
+}
+
+func (s *DaprHostMemberState) recordEvent(op MembershipEventOp, member *DaprHostMember) {
+	if s.events == nil {
+		return
+	}
+	s.events.add(MembershipEvent{
+		Timestamp: s.now(),
+		Op:        op,
+		Name:      member.Name,
+		AppID:     member.AppID,
+	})
+}
@@ -0,0 +1,88 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentEvents(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	host := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	}
+
+	// act
+	s.upsertMember(host)
+	s.upsertMember(host)
+	s.drainMember(host.Name)
+	s.undrainMember(host.Name)
+	s.removeMember(host)
+
+	// assert
+	events := s.RecentEvents()
+	ops := make([]MembershipEventOp, len(events))
+	for i, e := range events {
+		ops[i] = e.Op
+		assert.Equal(t, "127.0.0.1:8080", e.Name)
+		assert.Equal(t, "FakeID", e.AppID)
+		assert.False(t, e.Timestamp.IsZero())
+	}
+	assert.Equal(t, []MembershipEventOp{
+		MembershipEventAdd,
+		MembershipEventDrain,
+		MembershipEventUndrain,
+		MembershipEventRemove,
+	}, ops)
+}
+
+func TestSetEventLogSize(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	for i := 0; i < 5; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:  fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID: "FakeID",
+		})
+	}
+
+	t.Run("shrink keeps the recent events", func(t *testing.T) {
+		// act
+		s.SetEventLogSize(3)
+
+		// assert
+		events := s.RecentEvents()
+		assert.Equal(t, 3, len(events))
+		assert.Equal(t, "127.0.0.1:8082", events[0].Name)
+		assert.Equal(t, "127.0.0.1:8084", events[2].Name)
+	})
+
+	t.Run("oldest event is evicted", func(t *testing.T) {
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+
+		// assert
+		events := s.RecentEvents()
+		assert.Equal(t, 3, len(events))
+		assert.Equal(t, "127.0.0.1:8083", events[0].Name)
+		assert.Equal(t, MembershipEventRemove, events[2].Op)
+	})
+
+	t.Run("zero disables the log", func(t *testing.T) {
+		// act
+		s.SetEventLogSize(0)
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+
+		// assert
+		assert.Empty(t, s.RecentEvents())
+	})
+}
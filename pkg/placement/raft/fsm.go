@@ -6,6 +6,7 @@
 package raft
 
 import (
+	"context"
 	"io"
 	"strconv"
 	"sync"
@@ -24,6 +25,9 @@ const (
 	MemberUpsert CommandType = 0
 	// MemberRemove is the command to remove member from actor host member state
 	MemberRemove CommandType = 1
+	// TableGenerationFlush is the command to apply the increase of the table
+	// generation deferred by the generation window
+	TableGenerationFlush CommandType = 2
 
 	// TableDisseminate is the reserved command for dissemination loop
 	TableDisseminate CommandType = 100
@@ -58,6 +62,8 @@ func (c *FSM) State() *DaprHostMemberState {
 func (c *FSM) PlacementState() *v1pb.PlacementTables {
 	c.stateLock.RLock()
 	defer c.stateLock.RUnlock()
+	c.state.lock.RLock()
+	defer c.state.lock.RUnlock()
 
 	newTable := &v1pb.PlacementTables{
 		Version: strconv.FormatUint(c.state.TableGeneration, 10),
@@ -67,12 +73,17 @@ func (c *FSM) PlacementState() *v1pb.PlacementTables {
 	entries := c.state.hashingTableMap
 	for k, v := range entries {
 		hosts, sortedSet, loadMap, totalLoad := v.GetInternals()
+		// copy the internals because emptied hashing tables are reused.
 		table := v1pb.PlacementTable{
-			Hosts:     hosts,
-			SortedSet: sortedSet,
+			Hosts:     make(map[uint64]string, len(hosts)),
+			SortedSet: make([]uint64, len(sortedSet)),
 			TotalLoad: totalLoad,
 			LoadMap:   make(map[string]*v1pb.Host),
 		}
+		for hk, hv := range hosts {
+			table.Hosts[hk] = hv
+		}
+		copy(table.SortedSet, sortedSet)
 
 		for lk, lv := range loadMap {
 			h := v1pb.Host{
@@ -98,7 +109,12 @@ func (c *FSM) upsertMember(cmdData []byte) (bool, error) {
 		return false, err
 	}
 
-	return c.state.upsertMember(&host), nil
+	var result UpsertResult
+	err := c.state.applyCommitted(func() (err error) {
+		result, err = c.state.validateAndUpsertUnfrozen(&host)
+		return err
+	})
+	return result.TableChanged(), err
 }
 
 func (c *FSM) removeMember(cmdData []byte) (bool, error) {
@@ -110,7 +126,29 @@ func (c *FSM) removeMember(cmdData []byte) (bool, error) {
 		return false, err
 	}
 
-	return c.state.removeMember(&host), nil
+	var existed, updated bool
+	err := c.state.applyCommitted(func() error {
+		existed, _, updated = c.state.applyMemberRemove(&host)
+		if updated {
+			c.state.incTableGeneration()
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	if !existed {
+		logging.Warnf("cannot remove unknown host: %s", host.Name)
+	}
+
+	return updated, nil
+}
+
+func (c *FSM) flushGeneration() bool {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	return c.state.flushPending()
 }
 
 // Apply log is invoked once a log entry is committed.
@@ -130,6 +168,8 @@ func (c *FSM) Apply(log *raft.Log) interface{} {
 		updated, err = c.upsertMember(buf[1:])
 	case MemberRemove:
 		updated, err = c.removeMember(buf[1:])
+	case TableGenerationFlush:
+		updated = c.flushGeneration()
 	default:
 		err = errors.New("unimplemented command")
 	}
@@ -137,6 +177,7 @@ func (c *FSM) Apply(log *raft.Log) interface{} {
 	if err != nil {
 		return err
 	}
+	c.state.setIndex(log.Index)
 
 	return updated
 }
@@ -160,11 +201,22 @@ func (c *FSM) Restore(old io.ReadCloser) error {
 	if err := dec.Decode(&members); err != nil {
 		return err
 	}
+	if err := migrateState(&members); err != nil {
+		return err
+	}
 
+	// the restored state is published before its hashing tables are rebuilt,
+	// so that the callers of State() see it, and its mutations return
+	// ErrRestoring, instead of mutating the replaced state meanwhile.
+	index, generation := members.Index, members.TableGeneration
 	c.stateLock.Lock()
+	members.copyRuntimeConfig(c.state)
+	members.restoring = true
 	c.state = &members
-	c.state.restoreHashingTables()
 	c.stateLock.Unlock()
 
-	return nil
+	// background context is never cancelled.
+	return members.rebuildHashingTables(context.Background(), func() {
+		members.seedGenerations(index, generation)
+	})
 }
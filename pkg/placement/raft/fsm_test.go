@@ -7,9 +7,12 @@ package raft
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"testing"
+	"time"
 
+	"github.com/dapr/dapr/pkg/placement/hashing"
 	"github.com/hashicorp/raft"
 	"github.com/stretchr/testify/assert"
 )
@@ -40,6 +43,7 @@ func TestFSMApply(t *testing.T) {
 		assert.True(t, updated)
 		assert.Equal(t, uint64(1), fsm.state.TableGeneration)
 		assert.Equal(t, 1, len(fsm.state.Members))
+		assert.Equal(t, uint64(1), fsm.state.LastIndex())
 	})
 
 	t.Run("removeMember", func(t *testing.T) {
@@ -63,9 +67,69 @@ func TestFSMApply(t *testing.T) {
 		assert.True(t, updated)
 		assert.Equal(t, uint64(2), fsm.state.TableGeneration)
 		assert.Equal(t, 0, len(fsm.state.Members))
+		assert.Equal(t, uint64(2), fsm.state.LastIndex())
 	})
 }
 
+func TestFSMApplyFrozen(t *testing.T) {
+	// arrange
+	fsm := newFSM()
+	fsm.State().Freeze()
+	cmdLog, err := makeRaftLogCommand(MemberUpsert, DaprHostMember{
+		Name:     "127.0.0.1:3030",
+		AppID:    "fakeAppID",
+		Entities: []string{"actorTypeOne"},
+	})
+	assert.NoError(t, err)
+
+	// act
+	resp := fsm.Apply(&raft.Log{Index: 1, Term: 1, Type: raft.LogCommand, Data: cmdLog})
+
+	// assert
+	assert.Equal(t, true, resp)
+	assert.True(t, fsm.State().Frozen())
+	assert.Equal(t, 1, fsm.State().MemberCount())
+	assert.Equal(t, uint64(1), fsm.State().LastIndex())
+	assert.Equal(t, uint64(1), fsm.State().LastGeneration())
+
+	t.Run("remove is applied", func(t *testing.T) {
+		cmdLog, err := makeRaftLogCommand(MemberRemove, DaprHostMember{Name: "127.0.0.1:3030"})
+		assert.NoError(t, err)
+
+		// act
+		resp := fsm.Apply(&raft.Log{Index: 2, Term: 1, Type: raft.LogCommand, Data: cmdLog})
+
+		// assert
+		assert.Equal(t, true, resp)
+		assert.Equal(t, 0, fsm.State().MemberCount())
+		assert.Equal(t, uint64(2), fsm.State().LastIndex())
+	})
+}
+
+func TestFSMApplyNameCollision(t *testing.T) {
+	// arrange
+	fsm := newFSM()
+	fsm.State().SetNameCollisionWindow(time.Hour)
+	apply := func(index uint64, appID string) interface{} {
+		cmdLog, err := makeRaftLogCommand(MemberUpsert, DaprHostMember{
+			Name:     "127.0.0.1:3030",
+			AppID:    appID,
+			Entities: []string{"actorTypeOne"},
+		})
+		assert.NoError(t, err)
+		return fsm.Apply(&raft.Log{Index: index, Term: 1, Type: raft.LogCommand, Data: cmdLog})
+	}
+	apply(1, "fakeAppID")
+
+	// act
+	resp := apply(2, "fakeAppID_2")
+
+	// assert
+	assert.Equal(t, true, resp)
+	assert.Equal(t, "fakeAppID_2", fsm.State().Members["127.0.0.1:3030"].AppID)
+	assert.Equal(t, uint64(2), fsm.State().LastIndex())
+}
+
 func TestRestore(t *testing.T) {
 	// arrange
 	fsm := newFSM()
@@ -89,6 +153,146 @@ func TestRestore(t *testing.T) {
 	assert.Equal(t, 2, len(fsm.State().hashingTableMap))
 }
 
+func TestRestoreReplicationFactor(t *testing.T) {
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	data, err := marshalMsgPack(s)
+	assert.NoError(t, err)
+
+	t.Run("factor set before restore", func(t *testing.T) {
+		// arrange
+		hashing.SetReplicationFactor(10)
+		defer hashing.SetReplicationFactor(0)
+		fsm := newFSM()
+
+		// act
+		err := fsm.Restore(ioutil.NopCloser(bytes.NewBuffer(data)))
+
+		// assert
+		assert.NoError(t, err)
+		_, sortedSet, _, _ := fsm.State().hashingTableMap["actorTypeOne"].GetInternals()
+		assert.Equal(t, 10, len(sortedSet))
+	})
+
+	t.Run("factor set after restore", func(t *testing.T) {
+		// arrange
+		hashing.SetReplicationFactor(0)
+		defer hashing.SetReplicationFactor(0)
+		fsm := newFSM()
+		err := fsm.Restore(ioutil.NopCloser(bytes.NewBuffer(data)))
+		assert.NoError(t, err)
+
+		// act
+		hashing.SetReplicationFactor(10)
+		fsm.State().upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		_, sortedSet, _, _ := fsm.State().hashingTableMap["actorTypeOne"].GetInternals()
+		assert.Equal(t, 10, len(sortedSet))
+		host, _, ok := fsm.State().ResolveActorHost("actorTypeOne", "actor1")
+		assert.True(t, ok)
+		assert.Equal(t, "127.0.0.1:8081", host)
+	})
+}
+
+func TestRestoreGenerations(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeTwo"},
+	})
+	s.setIndex(7)
+
+	t.Run("seeded from the snapshot checkpoint", func(t *testing.T) {
+		fsm := newFSM()
+		data, err := marshalMsgPack(s.clone())
+		assert.NoError(t, err)
+
+		// act
+		err = fsm.Restore(ioutil.NopCloser(bytes.NewBuffer(data)))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(7), fsm.State().LastIndex())
+		assert.Equal(t, uint64(2), fsm.State().LastGeneration())
+		assert.Equal(t, uint64(1), fsm.State().EntityHostSetGeneration("actorTypeOne"))
+		assert.Equal(t, uint64(2), fsm.State().EntityHostSetGeneration("actorTypeTwo"))
+		assert.Empty(t, fsm.State().HostSetGenerations)
+	})
+
+	t.Run("snapshot without checkpoint", func(t *testing.T) {
+		fsm := newFSM()
+		data, err := marshalMsgPack(s)
+		assert.NoError(t, err)
+
+		// act
+		err = fsm.Restore(ioutil.NopCloser(bytes.NewBuffer(data)))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), fsm.State().EntityHostSetGeneration("actorTypeOne"))
+		assert.Equal(t, uint64(2), fsm.State().EntityHostSetGeneration("actorTypeTwo"))
+	})
+}
+
+func TestGenerationWindowReplicas(t *testing.T) {
+	// arrange
+	entries := make([]*raft.Log, 0, 4)
+	for i := 0; i < 3; i++ {
+		cmdLog, err := makeRaftLogCommand(MemberUpsert, DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:%d", 8080+i),
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+		assert.NoError(t, err)
+		entries = append(entries, &raft.Log{Index: uint64(i + 1), Term: 1, Type: raft.LogCommand, Data: cmdLog})
+	}
+	cmdLog, err := makeRaftLogCommand(TableGenerationFlush, DaprHostMember{})
+	assert.NoError(t, err)
+	entries = append(entries, &raft.Log{Index: 4, Term: 1, Type: raft.LogCommand, Data: cmdLog})
+
+	newReplica := func() *FSM {
+		fsm := newFSM()
+		fsm.State().SetGenerationWindow(10)
+		return fsm
+	}
+	leader := newReplica()
+	for _, e := range entries[:2] {
+		leader.Apply(e)
+	}
+
+	// act
+	data, err := marshalMsgPack(leader.State().clone())
+	assert.NoError(t, err)
+	restored := newReplica()
+	assert.NoError(t, restored.Restore(ioutil.NopCloser(bytes.NewBuffer(data))))
+	for _, e := range entries[2:] {
+		leader.Apply(e)
+		restored.Apply(e)
+	}
+
+	// assert
+	assert.Equal(t, uint64(2), leader.State().LastGeneration())
+	assert.Equal(t, leader.State().LastGeneration(), restored.State().LastGeneration())
+	assert.False(t, restored.State().PendingGeneration())
+	assert.Equal(t, leader.PlacementState().Version, restored.PlacementState().Version)
+}
+
 func TestPlacementState(t *testing.T) {
 	fsm := newFSM()
 	m := DaprHostMember{
@@ -110,3 +314,132 @@ func TestPlacementState(t *testing.T) {
 	assert.Equal(t, "1", newTable.Version)
 	assert.Equal(t, 2, len(newTable.Entries))
 }
+
+func TestPlacementStateAppIDChange(t *testing.T) {
+	// arrange
+	fsm := newFSM()
+	apply := func(index uint64, appID string) {
+		cmdLog, err := makeRaftLogCommand(MemberUpsert, DaprHostMember{
+			Name:     "127.0.0.1:3030",
+			AppID:    appID,
+			Entities: []string{"actorTypeOne"},
+		})
+		assert.NoError(t, err)
+		fsm.Apply(&raft.Log{Index: index, Term: 1, Type: raft.LogCommand, Data: cmdLog})
+	}
+	apply(1, "fakeAppID")
+	before := fsm.PlacementState()
+
+	// act
+	apply(2, "fakeAppID_2")
+	after := fsm.PlacementState()
+
+	// assert
+	assert.NotEqual(t, before.Version, after.Version)
+	assert.Equal(t, "fakeAppID_2", after.Entries["actorTypeOne"].LoadMap["127.0.0.1:3030"].Id)
+}
+
+func TestRestoreKeepsObservers(t *testing.T) {
+	// arrange
+	fsm := newFSM()
+	o := &fakeObserver{}
+	fsm.State().RegisterObserver(o)
+	fsm.State().SetReplicationFactor(3)
+
+	data, err := marshalMsgPack(newDaprHostMemberState())
+	assert.NoError(t, err)
+
+	// act
+	err = fsm.Restore(ioutil.NopCloser(bytes.NewBuffer(data)))
+	assert.NoError(t, err)
+	fsm.State().upsertMember(&DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID"})
+
+	// assert
+	assert.Equal(t, []string{"added:127.0.0.1:8080"}, o.events)
+	assert.Equal(t, 3, fsm.State().replicationFactor)
+}
+
+func TestFSMApplyInvalidMember(t *testing.T) {
+	fsm := newFSM()
+	cmdLog, err := makeRaftLogCommand(MemberUpsert, DaprHostMember{
+		Name:     "127.0.0.1:3030",
+		AppID:    "fakeAppID",
+		Entities: []string{""},
+	})
+	assert.NoError(t, err)
+
+	resp := fsm.Apply(&raft.Log{
+		Index: 1,
+		Term:  1,
+		Type:  raft.LogCommand,
+		Data:  cmdLog,
+	})
+
+	_, ok := resp.(error)
+	assert.True(t, ok)
+	assert.Equal(t, 0, len(fsm.state.Members))
+}
+
+func TestRestoreSchemaVersion(t *testing.T) {
+	t.Run("migrate unversioned snapshot", func(t *testing.T) {
+		// arrange
+		fsm := newFSM()
+		s := newDaprHostMemberState()
+		s.SchemaVersion = 0
+		data, err := marshalMsgPack(s)
+		assert.NoError(t, err)
+
+		// act
+		err = fsm.Restore(ioutil.NopCloser(bytes.NewBuffer(data)))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, SchemaVersion, fsm.State().SchemaVersion)
+	})
+
+	t.Run("migrate version 1 snapshot", func(t *testing.T) {
+		// arrange
+		fsm := newFSM()
+		s := newDaprHostMemberState()
+		s.upsertMember(&DaprHostMember{
+			Name:      "127.0.0.1:8080",
+			AppID:     "FakeID",
+			Namespace: "ns1",
+			Entities:  []string{"actorTypeOne"},
+		})
+		s.upsertMember(&DaprHostMember{
+			Name:      "127.0.0.1:8081",
+			AppID:     "FakeID",
+			Namespace: "ns2",
+			Entities:  []string{"actorTypeOne"},
+		})
+		s.SchemaVersion = 1
+		s.NamespaceGenerations = nil
+		data, err := marshalMsgPack(s)
+		assert.NoError(t, err)
+
+		// act
+		err = fsm.Restore(ioutil.NopCloser(bytes.NewBuffer(data)))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, SchemaVersion, fsm.State().SchemaVersion)
+		assert.Equal(t, uint64(2), fsm.State().NamespaceGeneration("ns1"))
+		assert.Equal(t, uint64(2), fsm.State().NamespaceGeneration("ns2"))
+	})
+
+	t.Run("reject future snapshot", func(t *testing.T) {
+		// arrange
+		fsm := newFSM()
+		s := newDaprHostMemberState()
+		s.SchemaVersion = SchemaVersion + 1
+		data, err := marshalMsgPack(s)
+		assert.NoError(t, err)
+
+		// act
+		err = fsm.Restore(ioutil.NopCloser(bytes.NewBuffer(data)))
+
+		// assert
+		assert.Error(t, err)
+	})
+}
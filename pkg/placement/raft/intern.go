@@ -0,0 +1,54 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+// entityNamesMinSize is the size of the entity name table below which it is
+// not pruned.
+const entityNamesMinSize = 64
+
+// internEntities replaces the entity names with the shared copies of the
+// entity name table, so that the members reporting the same Actor Types don't
+// keep their own copies of the names. The caller must hold the write lock.
+func (s *DaprHostMemberState) internEntities(entities []string) {
+	for i, e := range entities {
+		entities[i] = s.internEntity(e)
+	}
+}
+
+// internEntity returns the shared copy of the entity name. Names which no
+// member reports any more are pruned once the table doubles in size since
+// the last pruning, so that Actor Type churn doesn't grow it unbounded. The
+// caller must hold the write lock.
+func (s *DaprHostMemberState) internEntity(name string) string {
+	if interned, ok := s.entityNames[name]; ok {
+		return interned
+	}
+
+	if len(s.entityNames) >= entityNamesMinSize && len(s.entityNames) >= 2*s.entityNamesLive {
+		s.pruneEntityNames()
+	}
+	if s.entityNames == nil {
+		s.entityNames = map[string]string{}
+	}
+	// the name is copied, so that it doesn't retain the buffer of the
+	// request it is sliced from.
+	interned := string([]byte(name))
+	s.entityNames[interned] = interned
+	return interned
+}
+
+// pruneEntityNames rebuilds the entity name table from the names reported
+// by the members. The caller must hold the write lock.
+func (s *DaprHostMemberState) pruneEntityNames() {
+	names := make(map[string]string, s.entityNamesLive)
+	for _, m := range s.Members {
+		for _, e := range m.Entities {
+			names[e] = e
+		}
+	}
+	s.entityNames = names
+	s.entityNamesLive = len(names)
+}
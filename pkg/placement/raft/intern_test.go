@@ -0,0 +1,84 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternEntities(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	for i := 0; i < 10; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:%d", 8080+i),
+			AppID:    "FakeID",
+			Entities: []string{fmt.Sprintf("actorType%s", "One"), fmt.Sprintf("actorType%s", "Two")},
+		})
+	}
+
+	t.Run("identical names share the table entry", func(t *testing.T) {
+		assert.Len(t, s.entityNames, 2)
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, s.Members["127.0.0.1:8085"].Entities)
+	})
+
+	t.Run("clone has its own table", func(t *testing.T) {
+		// act
+		cloned := s.clone()
+
+		// assert
+		assert.Equal(t, s.entityNames, cloned.entityNames)
+		assert.Equal(t, s.Members, cloned.Members)
+	})
+
+	t.Run("churn doesn't grow the table unbounded", func(t *testing.T) {
+		// act
+		for i := 0; i < 10*entityNamesMinSize; i++ {
+			s.setMemberEntities("127.0.0.1:8080", []string{fmt.Sprintf("ephemeralActorType%d", i)})
+		}
+
+		// assert
+		assert.True(t, len(s.entityNames) <= 2*entityNamesMinSize)
+		assert.Contains(t, s.entityNames, "actorTypeOne")
+		assert.Contains(t, s.entityNames, fmt.Sprintf("ephemeralActorType%d", 10*entityNamesMinSize-1))
+	})
+}
+
+func BenchmarkInternEntitiesMemory(b *testing.B) {
+	const hosts = 2000
+	entities := []string{"actorTypeOne", "actorTypeTwo", "actorTypeThree", "actorTypeFour"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		s := newDaprHostMemberState()
+		for j := 0; j < hosts; j++ {
+			// every host reports its own copies of the names, as decoded
+			// from separate requests.
+			reported := make([]string, len(entities))
+			for k, e := range entities {
+				reported[k] = string([]byte(e))
+			}
+			s.upsertMember(&DaprHostMember{
+				Name:     fmt.Sprintf("127.0.0.1:%d", 10000+j),
+				AppID:    "FakeID",
+				Entities: reported,
+			})
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc))/hosts, "heap-B/host")
+		runtime.KeepAlive(s)
+	}
+}
@@ -0,0 +1,121 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"sort"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+)
+
+// MutationMetrics receives the counts of membership mutations applied to
+// DaprHostMemberState.
+type MutationMetrics interface {
+	// IncUpserts is called for every applied member upsert, including no-ops.
+	IncUpserts()
+	// IncNoopUpserts is called when the upsert doesn't change the member.
+	IncNoopUpserts()
+	// IncRemovals is called when the member is removed.
+	IncRemovals()
+	// IncTableGenerations is called when TableGeneration is increased.
+	IncTableGenerations()
+}
+
+// RebalanceMetrics receives the magnitude of the rebalancing caused by
+// membership mutations.
+type RebalanceMetrics interface {
+	// ObserveRebalance is called for every hashing table changed by the
+	// mutations of a table generation, with the fraction of the hash space
+	// which is assigned to another host. Creating or deleting a hashing
+	// table reassigns the whole hash space.
+	ObserveRebalance(entity string, reassigned float64)
+}
+
+// SetMutationMetrics sets the metrics recorder for membership mutations.
+// nil disables the metrics.
+func (s *DaprHostMemberState) SetMutationMetrics(metrics MutationMetrics) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.metrics = metrics
+}
+
+func (s *DaprHostMemberState) recordUpsert(noop bool) {
+	if s.metrics == nil {
+		return
+	}
+	s.metrics.IncUpserts()
+	if noop {
+		s.metrics.IncNoopUpserts()
+	}
+}
+
+func (s *DaprHostMemberState) recordRemoval() {
+	if s.metrics != nil {
+		s.metrics.IncRemovals()
+	}
+}
+
+func (s *DaprHostMemberState) recordTableGeneration() {
+	if s.metrics != nil {
+		s.metrics.IncTableGenerations()
+	}
+}
+
+// SetRebalanceMetrics sets the recorder for the rebalance magnitude. It is
+// opt-in because every changed hashing table is walked before and after the
+// mutation. nil disables the metrics.
+func (s *DaprHostMemberState) SetRebalanceMetrics(metrics RebalanceMetrics) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.rebalanceMetrics = metrics
+	s.ringsBefore = nil
+}
+
+// snapshotRing keeps the ring of the key before its first change in the
+// current table generation, if the rebalance metrics are enabled. The caller
+// must hold the write lock.
+func (s *DaprHostMemberState) snapshotRing(key string) {
+	if s.rebalanceMetrics == nil || s.rebuildingTables {
+		return
+	}
+	if _, ok := s.ringsBefore[key]; ok {
+		return
+	}
+	if s.ringsBefore == nil {
+		s.ringsBefore = map[string][]hashing.RingPoint{}
+	}
+	var points []hashing.RingPoint
+	if t, ok := s.hashingTableMap[key]; ok {
+		points = t.Ring()
+	}
+	s.ringsBefore[key] = points
+}
+
+// recordRebalance reports the reassigned fraction of every ring snapshot by
+// snapshotRing, in the sorted order of the keys. The caller must hold the
+// write lock.
+func (s *DaprHostMemberState) recordRebalance() {
+	if s.rebalanceMetrics == nil || len(s.ringsBefore) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(s.ringsBefore))
+	for key := range s.ringsBefore {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var after []hashing.RingPoint
+		if t, ok := s.hashingTableMap[key]; ok {
+			after = t.Ring()
+		}
+		s.rebalanceMetrics.ObserveRebalance(key, hashing.ReassignedFraction(s.ringsBefore[key], after))
+	}
+	s.ringsBefore = nil
+}
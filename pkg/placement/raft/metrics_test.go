@@ -0,0 +1,154 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"testing"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMutationMetrics struct {
+	upserts          int
+	noopUpserts      int
+	removals         int
+	tableGenerations int
+}
+
+func (m *fakeMutationMetrics) IncUpserts()          { m.upserts++ }
+func (m *fakeMutationMetrics) IncNoopUpserts()      { m.noopUpserts++ }
+func (m *fakeMutationMetrics) IncRemovals()         { m.removals++ }
+func (m *fakeMutationMetrics) IncTableGenerations() { m.tableGenerations++ }
+
+func TestMutationMetrics(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	metrics := &fakeMutationMetrics{}
+	s.SetMutationMetrics(metrics)
+	testMember := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	}
+
+	// act
+	s.upsertMember(testMember)
+	s.upsertMember(testMember)
+	s.upsertMember(&DaprHostMember{Name: "127.0.0.1:8081", AppID: "FakeID_2"})
+	s.removeMember(testMember)
+	s.removeMember(testMember)
+
+	// assert
+	assert.Equal(t, &fakeMutationMetrics{
+		upserts:          3,
+		noopUpserts:      1,
+		removals:         1,
+		tableGenerations: 2,
+	}, metrics)
+}
+
+func TestNilMutationMetrics(t *testing.T) {
+	s := newDaprHostMemberState()
+	s.SetMutationMetrics(nil)
+
+	assert.NotPanics(t, func() {
+		s.upsertMember(&DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}})
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+	})
+}
+
+type fakeRebalanceMetrics struct {
+	entities   []string
+	reassigned []float64
+}
+
+func (m *fakeRebalanceMetrics) ObserveRebalance(entity string, reassigned float64) {
+	m.entities = append(m.entities, entity)
+	m.reassigned = append(m.reassigned, reassigned)
+}
+
+func TestRebalanceMetrics(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(100)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	metrics := &fakeRebalanceMetrics{}
+	s.SetRebalanceMetrics(metrics)
+	reset := func() {
+		metrics.entities, metrics.reassigned = nil, nil
+	}
+
+	t.Run("new tables are reassigned entirely", func(t *testing.T) {
+		reset()
+
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, metrics.entities)
+		assert.Equal(t, []float64{1, 1}, metrics.reassigned)
+	})
+
+	t.Run("joining host takes its coverage", func(t *testing.T) {
+		reset()
+
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeOne"}, metrics.entities)
+		coverage := s.hashingTableMap["actorTypeOne"].Coverage()
+		assert.InDelta(t, coverage["127.0.0.1:8081"], metrics.reassigned[0], 1e-9)
+	})
+
+	t.Run("leaving host gives up its coverage", func(t *testing.T) {
+		coverage := s.hashingTableMap["actorTypeOne"].Coverage()
+		reset()
+
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeOne"}, metrics.entities)
+		assert.InDelta(t, coverage["127.0.0.1:8081"], metrics.reassigned[0], 1e-9)
+	})
+
+	t.Run("no-op upsert", func(t *testing.T) {
+		reset()
+
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+
+		// assert
+		assert.Empty(t, metrics.entities)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		s.SetRebalanceMetrics(nil)
+		reset()
+
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+
+		// assert
+		assert.Empty(t, metrics.entities)
+		assert.Nil(t, s.ringsBefore)
+	})
+}
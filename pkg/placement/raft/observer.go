@@ -0,0 +1,227 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// MembershipObserver is notified of membership changes of DaprHostMemberState.
+// Observers are invoked synchronously after the state is mutated, so they
+// observe the changes in the order they are applied. Observers are called
+// while the state lock is held and must not call back into the state.
+type MembershipObserver interface {
+	// OnMemberAdded is called when a new member is added or an existing
+	// member is changed. The given member is a copy of the stored member.
+	OnMemberAdded(member *DaprHostMember)
+	// OnMemberRemoved is called when the member is removed.
+	OnMemberRemoved(name string)
+	// OnTableGeneration is called when TableGeneration is increased.
+	OnTableGeneration(generation uint64)
+}
+
+// PlacementObserver is optionally implemented by a MembershipObserver to be
+// notified when a host first enters any hashing table, such as to measure the
+// startup of the actor subsystem of the hosts.
+type PlacementObserver interface {
+	// OnFirstPlacement is called with the name of the host and the time from
+	// its CreatedAt to its PlacedAt. The lag is about zero for a host which
+	// reports Actor Types when it registers.
+	OnFirstPlacement(host string, lag time.Duration)
+}
+
+// RegisterObserver registers the observer for membership changes. The
+// observer is also notified of first placements if it implements
+// PlacementObserver.
+func (s *DaprHostMemberState) RegisterObserver(observer MembershipObserver) {
+	if observer == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.observers = append(s.observers, observer)
+}
+
+// SetEntityAvailabilityHooks sets the functions called with the hashing table
+// key when the first host of an Actor Type joins and when its last host
+// leaves, so that the Actor Type is unserviceable. Either may be nil. Like
+// observers, the hooks are called while the state lock is held and must not
+// call back into the state. Rebuilding the hashing tables from Members, such
+// as on snapshot restore, doesn't call them.
+func (s *DaprHostMemberState) SetEntityAvailabilityHooks(onAvailable, onUnavailable func(entity string)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onEntityAvailable = onAvailable
+	s.onEntityUnavailable = onUnavailable
+}
+
+func (s *DaprHostMemberState) notifyMemberAdded(member *DaprHostMember) {
+	for _, o := range s.observers {
+		o.OnMemberAdded(member.clone())
+	}
+}
+
+func (s *DaprHostMemberState) notifyMemberRemoved(name string) {
+	for _, o := range s.observers {
+		o.OnMemberRemoved(name)
+	}
+}
+
+// AwaitStable blocks until TableGeneration has not been increased for the
+// quiet duration, or returns the error of ctx when it is done first. It
+// waits for the changes of TableGeneration instead of polling it.
+func (s *DaprHostMemberState) AwaitStable(ctx context.Context, quiet time.Duration) error {
+	timer := time.NewTimer(quiet)
+	defer timer.Stop()
+
+	for {
+		changed := s.generationChangedCh()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case <-changed:
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(quiet)
+		}
+	}
+}
+
+// generationChangedCh returns the channel closed on the next increase of
+// TableGeneration.
+func (s *DaprHostMemberState) generationChangedCh() <-chan struct{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.generationChanged == nil {
+		s.generationChanged = make(chan struct{})
+	}
+	return s.generationChanged
+}
+
+func (s *DaprHostMemberState) notifyTableGeneration() {
+	for _, o := range s.observers {
+		o.OnTableGeneration(s.TableGeneration)
+	}
+	if s.generationChanged != nil {
+		close(s.generationChanged)
+		s.generationChanged = nil
+	}
+}
+
+// markPlaced sets PlacedAt of the member which is added to a hashing table,
+// if it has never been in one, and notifies the placement observers.
+// Rebuilding the hashing tables from Members sets PlacedAt of the members
+// without one, but doesn't notify. The caller must hold the write lock.
+func (s *DaprHostMemberState) markPlaced(m *DaprHostMember) {
+	if !m.PlacedAt.IsZero() {
+		return
+	}
+	m.PlacedAt = s.now()
+	if s.rebuildingTables {
+		return
+	}
+
+	lag := m.PlacedAt.Sub(m.CreatedAt)
+	for _, o := range s.observers {
+		if p, ok := o.(PlacementObserver); ok {
+			p.OnFirstPlacement(m.Name, lag)
+		}
+	}
+}
+
+func (s *DaprHostMemberState) notifyEntityAvailable(key string) {
+	if s.rebuildingTables {
+		return
+	}
+	delete(s.OrphanedAt, key)
+	if s.onEntityAvailable != nil {
+		s.onEntityAvailable(key)
+	}
+}
+
+func (s *DaprHostMemberState) notifyEntityUnavailable(key string) {
+	if s.rebuildingTables {
+		return
+	}
+	s.recordOrphan(key)
+	if s.onEntityUnavailable != nil {
+		s.onEntityUnavailable(key)
+	}
+}
+
+// orphanRetention is the age beyond which the orphaned keys are dropped from
+// OrphanedAt when another key is orphaned.
+const orphanRetention = 24 * time.Hour
+
+// recordOrphan records the time when the last host left the hashing table of
+// the key. The caller must hold the write lock.
+func (s *DaprHostMemberState) recordOrphan(key string) {
+	now := s.now()
+	for k, t := range s.OrphanedAt {
+		if now.Sub(t) > orphanRetention {
+			delete(s.OrphanedAt, k)
+		}
+	}
+	if s.OrphanedAt == nil {
+		s.OrphanedAt = map[string]time.Time{}
+	}
+	s.OrphanedAt[key] = now
+}
+
+// RecentlyOrphaned returns the sorted hashing table keys whose last host left
+// within the window and which have had no host since, so that a scheduler can
+// start replacements. Unlike the entity availability hooks, the keys can be
+// queried after the fact and survive snapshots. Keys orphaned longer ago than
+// the window are not returned. Rebuilding the hashing tables from Members
+// doesn't orphan any key.
+func (s *DaprHostMemberState) RecentlyOrphaned(window time.Duration) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	now := s.now()
+	keys := map[string]struct{}{}
+	for key, t := range s.OrphanedAt {
+		if _, ok := s.hashingTableMap[key]; ok {
+			continue
+		}
+		if now.Sub(t) <= window {
+			keys[key] = struct{}{}
+		}
+	}
+	return sortedKeys(keys)
+}
+
+// notifyEntityAvailabilityChanges calls the entity availability hooks for the
+// keys which have hosts in only one of before and after, in sorted order.
+func (s *DaprHostMemberState) notifyEntityAvailabilityChanges(before, after map[string]map[string]ringHost) {
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		wasAvailable, isAvailable := len(before[key]) > 0, len(after[key]) > 0
+		switch {
+		case isAvailable && !wasAvailable:
+			s.notifyEntityAvailable(key)
+		case wasAvailable && !isAvailable:
+			s.notifyEntityUnavailable(key)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeObserver struct {
+	events []string
+}
+
+func (o *fakeObserver) OnMemberAdded(member *DaprHostMember) {
+	o.events = append(o.events, "added:"+member.Name)
+}
+
+func (o *fakeObserver) OnMemberRemoved(name string) {
+	o.events = append(o.events, "removed:"+name)
+}
+
+func (o *fakeObserver) OnTableGeneration(generation uint64) {
+	o.events = append(o.events, "generation")
+}
+
+func TestMembershipObserver(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	o := &fakeObserver{}
+	s.RegisterObserver(o)
+	testMember := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	}
+
+	t.Run("new actor member", func(t *testing.T) {
+		o.events = nil
+
+		// act
+		s.upsertMember(testMember)
+
+		// assert
+		assert.Equal(t, []string{"added:127.0.0.1:8080", "generation"}, o.events)
+	})
+
+	t.Run("idempotent upsert", func(t *testing.T) {
+		o.events = nil
+
+		// act
+		s.upsertMember(testMember)
+
+		// assert
+		assert.Empty(t, o.events)
+	})
+
+	t.Run("non actor member", func(t *testing.T) {
+		o.events = nil
+
+		// act
+		s.upsertMember(&DaprHostMember{Name: "127.0.0.1:8081", AppID: "FakeID_2"})
+
+		// assert
+		assert.Equal(t, []string{"added:127.0.0.1:8081"}, o.events)
+	})
+
+	t.Run("remove members", func(t *testing.T) {
+		o.events = nil
+
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+
+		// assert
+		assert.Equal(t, []string{"removed:127.0.0.1:8080", "generation", "removed:127.0.0.1:8081"}, o.events)
+	})
+}
+
+func TestEntityAvailabilityHooks(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	available, unavailable := []string{}, []string{}
+	s.SetEntityAvailabilityHooks(
+		func(entity string) { available = append(available, entity) },
+		func(entity string) { unavailable = append(unavailable, entity) })
+	reset := func() {
+		available, unavailable = []string{}, []string{}
+	}
+
+	t.Run("first host creates the ring", func(t *testing.T) {
+		reset()
+
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:      "127.0.0.1:8080",
+			AppID:     "FakeID",
+			Namespace: "ns1",
+			Entities:  []string{"actorTypeOne"},
+		})
+		s.upsertMember(&DaprHostMember{
+			Name:      "127.0.0.1:8081",
+			AppID:     "FakeID_2",
+			Namespace: "ns1",
+			Entities:  []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, []string{EntityKey("ns1", "actorTypeOne")}, available)
+		assert.Empty(t, unavailable)
+	})
+
+	t.Run("last host deletes the ring", func(t *testing.T) {
+		reset()
+
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+		assert.Empty(t, unavailable)
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+
+		// assert
+		assert.Empty(t, available)
+		assert.Equal(t, []string{EntityKey("ns1", "actorTypeOne")}, unavailable)
+	})
+
+	t.Run("replace members", func(t *testing.T) {
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+		reset()
+
+		// act
+		s.ReplaceMembers([]*DaprHostMember{{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeTwo", "actorTypeThree"},
+		}})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeThree"}, available)
+		assert.Equal(t, []string{"actorTypeOne"}, unavailable)
+	})
+
+	t.Run("restore doesn't call the hooks", func(t *testing.T) {
+		reset()
+
+		// act
+		s.restoreHashingTables()
+
+		// assert
+		assert.Empty(t, available)
+		assert.Empty(t, unavailable)
+	})
+
+	t.Run("nil hooks", func(t *testing.T) {
+		s.SetEntityAvailabilityHooks(nil, nil)
+
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+
+		// assert
+		assert.Empty(t, s.hashingTableMap)
+	})
+}
+
+func TestAwaitStable(t *testing.T) {
+	upsertHost := func(s *DaprHostMemberState, i int) {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:%d", 8080+i),
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+
+	t.Run("settled state", func(t *testing.T) {
+		// arrange
+		s := newDaprHostMemberState()
+		upsertHost(s, 0)
+
+		// act
+		err := s.AwaitStable(context.Background(), 10*time.Millisecond)
+
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("generation change restarts the quiet period", func(t *testing.T) {
+		// arrange
+		s := newDaprHostMemberState()
+		start := time.Now()
+		go func() {
+			time.Sleep(40 * time.Millisecond)
+			upsertHost(s, 1)
+		}()
+
+		// act
+		err := s.AwaitStable(context.Background(), 80*time.Millisecond)
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, time.Since(start) >= 120*time.Millisecond)
+	})
+
+	t.Run("context expires while the state keeps changing", func(t *testing.T) {
+		// arrange
+		s := newDaprHostMemberState()
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				case <-time.After(5 * time.Millisecond):
+					upsertHost(s, i)
+				}
+			}
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		// act
+		err := s.AwaitStable(ctx, 50*time.Millisecond)
+		close(done)
+		<-stopped
+
+		// assert
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+}
+
+func TestRecentlyOrphaned(t *testing.T) {
+	// arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newDaprHostMemberState()
+	s.SetClock(func() time.Time { return now })
+	for i, entity := range []string{"actorTypeOne", "actorTypeTwo"} {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:%d", 8080+i),
+			AppID:    "FakeID",
+			Entities: []string{entity},
+		})
+	}
+
+	t.Run("last host leaves", func(t *testing.T) {
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+		now = now.Add(time.Minute)
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, s.RecentlyOrphaned(time.Hour))
+	})
+
+	t.Run("entries age out after the window", func(t *testing.T) {
+		// act
+		now = now.Add(30 * time.Second)
+
+		// assert
+		assert.Equal(t, []string{"actorTypeTwo"}, s.RecentlyOrphaned(time.Minute))
+	})
+
+	t.Run("orphans survive clone", func(t *testing.T) {
+		// act
+		cloned := s.clone()
+		cloned.SetClock(func() time.Time { return now })
+
+		// assert
+		assert.Equal(t, s.RecentlyOrphaned(time.Hour), cloned.RecentlyOrphaned(time.Hour))
+	})
+
+	t.Run("new host clears the orphan", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8082",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeTwo"}, s.RecentlyOrphaned(time.Hour))
+	})
+}
+
+type fakePlacementObserver struct {
+	fakeObserver
+	lags map[string]time.Duration
+}
+
+func (o *fakePlacementObserver) OnFirstPlacement(host string, lag time.Duration) {
+	o.lags[host] = lag
+}
+
+func TestFirstPlacement(t *testing.T) {
+	// arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newDaprHostMemberState()
+	s.SetClock(func() time.Time { return now })
+	o := &fakePlacementObserver{lags: map[string]time.Duration{}}
+	s.RegisterObserver(o)
+
+	t.Run("actor host is placed when it registers", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, map[string]time.Duration{"127.0.0.1:8080": 0}, o.lags)
+		assert.Equal(t, now, s.Members["127.0.0.1:8080"].PlacedAt)
+	})
+
+	t.Run("non actor host is placed when it reports actor types", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{Name: "127.0.0.1:8081", AppID: "FakeID_2"})
+		assert.True(t, s.Members["127.0.0.1:8081"].PlacedAt.IsZero())
+		now = now.Add(time.Minute)
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, time.Minute, o.lags["127.0.0.1:8081"])
+	})
+
+	t.Run("later placements are not reported", func(t *testing.T) {
+		o.lags = map[string]time.Duration{}
+
+		// act
+		now = now.Add(time.Minute)
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+
+		// assert
+		assert.Empty(t, o.lags)
+		assert.Equal(t, now.Add(-2*time.Minute), s.Members["127.0.0.1:8080"].PlacedAt)
+	})
+
+	t.Run("rebuilding the tables is not reported", func(t *testing.T) {
+		o.lags = map[string]time.Duration{}
+
+		// act
+		s.restoreHashingTables()
+
+		// assert
+		assert.Empty(t, o.lags)
+	})
+
+	t.Run("placement survives clone", func(t *testing.T) {
+		// act
+		cloned := s.clone()
+
+		// assert
+		assert.Equal(t, s.Members["127.0.0.1:8081"].PlacedAt, cloned.Members["127.0.0.1:8081"].PlacedAt)
+	})
+}
@@ -0,0 +1,114 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"github.com/dapr/dapr/pkg/placement/hashing"
+)
+
+// RegionLabel is the label of the region of a host, used by
+// ResolveActorHostInRegion.
+const RegionLabel = "region"
+
+// ResolveActorHostInRegion returns the host which owns the actor ID of the
+// given Actor Type among the hosts of the hashing table in the region, given
+// by their RegionLabel. If the table has no host in the region, the actor ID
+// is resolved against the whole table as ResolveActorHost does and fellBack
+// is true. A pinned actor resolves to its pinned host, which falls back
+// unless the host is in the region. host is empty when the actor ID can't be
+// resolved at all. entity is the hashing table key built by EntityKey.
+func (s *DaprHostMemberState) ResolveActorHostInRegion(entity, actorID, region string) (host string, fellBack bool) {
+	if !s.mayHaveEntity(entity) {
+		return "", true
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	entity = s.canonicalEntity(entity)
+	if name, ok := s.Pins[entity][actorID]; ok {
+		if m, ok := s.Members[name]; ok {
+			return m.Name, m.Labels[RegionLabel] != region
+		}
+	}
+
+	if t, ok := s.regionRings[entity][region]; ok {
+		if h, err := t.GetHost(actorID); err == nil {
+			return h.Name, false
+		}
+	}
+
+	host, _, _ = s.resolveActorHost(entity, s.hashingTableMap[entity], actorID)
+	return host, true
+}
+
+// refreshRegionRings rebuilds the per region hashing tables of the keys from
+// their hashing tables and the regions of their hosts. Hosts without a region
+// are only in the hashing tables. The caller must hold the write lock.
+func (s *DaprHostMemberState) refreshRegionRings(keys map[string]struct{}) {
+	for key := range keys {
+		for _, t := range s.regionRings[key] {
+			hashingTablePool.Put(t)
+		}
+		delete(s.regionRings, key)
+
+		t, ok := s.hashingTableMap[key]
+		if !ok {
+			continue
+		}
+		_, _, loadMap, _ := t.GetInternals()
+		rings := map[string]*hashing.Consistent{}
+		for name, h := range loadMap {
+			m, ok := s.Members[name]
+			if !ok || m.Labels[RegionLabel] == "" {
+				continue
+			}
+			region := m.Labels[RegionLabel]
+			if _, ok := rings[region]; !ok {
+				rings[region] = s.newHashingTable()
+			}
+			rings[region].AddWithWeight(name, h.AppID, 0, h.Weight)
+		}
+		if len(rings) == 0 {
+			continue
+		}
+		if s.regionRings == nil {
+			s.regionRings = map[string]map[string]*hashing.Consistent{}
+		}
+		s.regionRings[key] = rings
+	}
+}
+
+// refreshMemberRegionRings rebuilds the per region hashing tables of the keys
+// of the member, when its region changes without any table change.
+// The caller must hold the write lock.
+func (s *DaprHostMemberState) refreshMemberRegionRings(m *DaprHostMember) {
+	keys := map[string]struct{}{}
+	addEntityKeys(keys, m)
+	s.refreshRegionRings(keys)
+}
+
+// refreshAllRegionRings rebuilds the per region hashing tables of all keys.
+// The caller must hold the write lock.
+func (s *DaprHostMemberState) refreshAllRegionRings() {
+	keys := make(map[string]struct{}, len(s.hashingTableMap)+len(s.regionRings))
+	for key := range s.hashingTableMap {
+		keys[key] = struct{}{}
+	}
+	for key := range s.regionRings {
+		keys[key] = struct{}{}
+	}
+	s.refreshRegionRings(keys)
+}
+
+// ringOpKeys returns the hashing table keys of the ring operations.
+func ringOpKeys(ops []ringOp) map[string]struct{} {
+	keys := make(map[string]struct{}, len(ops))
+	for _, op := range ops {
+		keys[op.key] = struct{}{}
+	}
+	return keys
+}
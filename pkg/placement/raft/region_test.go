@@ -0,0 +1,100 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveActorHostInRegion(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	regions := map[string]string{
+		"127.0.0.1:8080": "west",
+		"127.0.0.1:8081": "west",
+		"127.0.0.1:8082": "east",
+		"127.0.0.1:8083": "",
+	}
+	for name, region := range regions {
+		s.upsertMember(&DaprHostMember{
+			Name:     name,
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+			Labels:   map[string]string{RegionLabel: region},
+		})
+	}
+
+	t.Run("regional host is preferred", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			// act
+			host, fellBack := s.ResolveActorHostInRegion("actorTypeOne", fmt.Sprintf("actor%d", i), "west")
+
+			// assert
+			assert.False(t, fellBack)
+			assert.Equal(t, "west", regions[host])
+		}
+	})
+
+	t.Run("no host in the region falls back to the whole ring", func(t *testing.T) {
+		// act
+		host, fellBack := s.ResolveActorHostInRegion("actorTypeOne", "actor1", "north")
+
+		// assert
+		expected, _, _ := s.ResolveActorHost("actorTypeOne", "actor1")
+		assert.True(t, fellBack)
+		assert.Equal(t, expected, host)
+	})
+
+	t.Run("region change without table change", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8083",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+			Labels:   map[string]string{RegionLabel: "north"},
+		})
+		host, fellBack := s.ResolveActorHostInRegion("actorTypeOne", "actor1", "north")
+
+		// assert
+		assert.False(t, fellBack)
+		assert.Equal(t, "127.0.0.1:8083", host)
+	})
+
+	t.Run("removed host leaves its region", func(t *testing.T) {
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8082"})
+		_, fellBack := s.ResolveActorHostInRegion("actorTypeOne", "actor1", "east")
+
+		// assert
+		assert.True(t, fellBack)
+	})
+
+	t.Run("restored tables keep the regions", func(t *testing.T) {
+		// act
+		restored := s.cloneWithTables()
+		host, fellBack := restored.ResolveActorHostInRegion("actorTypeOne", "actor1", "north")
+
+		// assert
+		assert.False(t, fellBack)
+		assert.Equal(t, "127.0.0.1:8083", host)
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		// act
+		host, fellBack := s.ResolveActorHostInRegion("actorTypeTwo", "actor1", "west")
+
+		// assert
+		assert.True(t, fellBack)
+		assert.Empty(t, host)
+	})
+}
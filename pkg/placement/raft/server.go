@@ -229,6 +229,18 @@ func (s *Server) ApplyCommand(cmdType CommandType, data DaprHostMember) (bool, e
 	if !s.IsLeader() {
 		return false, errors.New("this is not the leader node")
 	}
+	// member commands are rejected before they are proposed, since the
+	// frozen state still applies every committed log.
+	if cmdType != TableGenerationFlush && s.fsm.State().Frozen() {
+		return false, ErrFrozen
+	}
+	// the name collision window depends on the clock of the leader, so the
+	// upsert is rejected before it is proposed rather than by the FSM.
+	if cmdType == MemberUpsert {
+		if err := s.fsm.State().CheckNameCollision(&data); err != nil {
+			return false, err
+		}
+	}
 
 	cmdLog, err := makeRaftLogCommand(cmdType, data)
 	if err != nil {
@@ -241,6 +253,9 @@ func (s *Server) ApplyCommand(cmdType CommandType, data DaprHostMember) (bool, e
 	}
 
 	resp := future.Response()
+	if err, ok := resp.(error); ok {
+		return false, err
+	}
 	return resp.(bool), nil
 }
 
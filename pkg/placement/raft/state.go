@@ -6,12 +6,44 @@
 package raft
 
 import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dapr/dapr/pkg/placement/hashing"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
 )
 
+// SchemaVersion is the current schema version of the serialized
+// DaprHostMemberState. Increase it whenever the serialized layout changes
+// and add the migration to migrateState.
+const SchemaVersion = 2
+
+// ErrMaxMembers is the cause of the error returned by upsertMember when a new
+// host would exceed the maximum number of members. See SetMaxMembers.
+var ErrMaxMembers = errors.New("maximum number of members reached")
+
+// ErrFrozen is returned by the mutations of the members while the state is
+// frozen. See Freeze.
+var ErrFrozen = errors.New("placement state is frozen")
+
+// ErrRestoring is returned by the mutations of the state while the hashing
+// tables are rebuilt from a snapshot, or after the rebuild is cancelled.
+var ErrRestoring = errors.New("placement state is being restored")
+
+// ErrNameCollision is the cause of the error returned by upsertMember when
+// another App ID recently registered the host name. See SetNameCollisionWindow.
+var ErrNameCollision = errors.New("host name is registered by another app id")
+
+// MutationValidator validates the member before upsertMember applies it. A
+// non-nil error rejects the upsert. See SetMutationValidator.
+type MutationValidator func(*DaprHostMember) error
+
 // DaprHostMember represents Dapr runtime host member, which can be
 // actor service host or normal host.
 type DaprHostMember struct {
@@ -19,18 +51,61 @@ type DaprHostMember struct {
 	Name string
 	// AppID is Dapr runtime app ID.
 	AppID string
+	// Namespace is the namespace of Dapr runtime. Hosts in different namespaces
+	// never share hashing tables even if they report the same Actor Types.
+	Namespace string
 	// Entities is the list of Actor Types which this Dapr runtime supports.
+	// upsertMember stores them sorted without duplicates.
 	Entities []string
+	// Labels are arbitrary key/value metadata of the host, such as region or zone.
+	// Labels do not affect the hashing tables.
+	Labels map[string]string
+	// Draining is true when the host is removed from the hashing tables
+	// while its member record is kept. See drainMember.
+	Draining bool
+	// Weight is the relative capacity of this host. A host with a larger weight
+	// gets proportionally more virtual nodes in the hashing tables. Zero means
+	// the default weight.
+	Weight int
+	// EntityWeights are the weights of this host per Actor Type, overriding
+	// Weight for the hashing table of the Actor Type.
+	EntityWeights map[string]int
+	// SkippedEntities are the sorted hashing table keys which this host is
+	// not added to because the tables were at their maximum replicas.
+	SkippedEntities []string
+	// Version is increased on every change of this host member by upsertMember.
+	// A new member starts at version 1.
+	Version uint64
+	// RequestID is the optional idempotency token of the registration. An
+	// upsert with the same RequestID as the last applied one of the host is
+	// ignored entirely, without even updating UpdatedAt.
+	RequestID string
 
 	// CreatedAt is the time when this host is first added.
 	CreatedAt time.Time
 	// UpdatedAt is the last time when this host member info is updated.
 	UpdatedAt time.Time
+	// DeletedAt is the time when this host is tombstoned by removeMember.
+	// It is zero unless the tombstone grace period is enabled.
+	DeletedAt time.Time
+	// PlacedAt is the time when this host is first added to any hashing
+	// table. It is zero while the host has never been in a hashing table.
+	PlacedAt time.Time
 }
 
 // DaprHostMemberState is the state to store Dapr runtime host and
 // consistent hashing tables.
+//
+// DaprHostMemberState is safe for concurrent use only through its methods.
+// Callers must not read or modify Members directly while the state is shared.
 type DaprHostMemberState struct {
+	// lock protects Members, TableGeneration, Pins, Aliases,
+	// EntityChangedAt, NamespaceGenerations, HostSetGenerations, OrphanedAt
+	// and hashingTableMap.
+	lock sync.RWMutex
+
+	// SchemaVersion is the schema version of the serialized state.
+	SchemaVersion int
 	// Index is the index number of raft log.
 	Index uint64
 	// Members includes Dapr runtime hosts.
@@ -40,128 +115,2353 @@ type DaprHostMemberState struct {
 	// This is increased whenever hashingTableMap is updated.
 	TableGeneration uint64
 
+	// Pins overrides the hashing tables for specific actors. It maps the
+	// hashing table key to actor ID to the host name which the actor is
+	// always resolved to while the host is a live member which is not
+	// draining and is in the hashing table of the key. Pins are not part of
+	// the disseminated placement tables, so only the lookups of this state
+	// honor them and Dapr runtimes keep routing by the hashing tables.
+	Pins map[string]map[string]string
+
+	// Aliases maps the alias of an Actor Type to its canonical Actor Type, so
+	// that actors of the alias resolve through the hashing table of the
+	// canonical one. Both are hashing table keys built by EntityKey. Like
+	// Pins, aliases are not disseminated to Dapr runtimes.
+	Aliases map[string]string
+
+	// EntityChangedAt is the last time when the hosts or the weights of the
+	// hashing table of each key changed. The key is built by EntityKey.
+	EntityChangedAt map[string]time.Time
+
+	// NamespaceGenerations is the generation of the hashing tables of each
+	// namespace. It is increased with TableGeneration, but only for the
+	// namespaces whose hashing tables are updated.
+	NamespaceGenerations map[string]uint64
+
+	// HostSetGenerations is the checkpoint of the host set generation of each
+	// hashing table key, written to snapshots by clone and used by
+	// restoreGenerations. It is empty in the live state.
+	HostSetGenerations map[string]uint64
+
+	// OrphanedAt is the time when the last host left the hashing table of each
+	// key, for the keys which have had no host since. See RecentlyOrphaned.
+	OrphanedAt map[string]time.Time
+
+	// GenerationPending and LastGenerationIndex are the checkpoint of the
+	// generation window, written to snapshots by clone and used by
+	// restoreGenerations, so that restored replicas close the window at the
+	// same log entry as the others. They are zero in the live state.
+	GenerationPending   bool
+	LastGenerationIndex uint64
+
 	// hashingTableMap is the map for storing consistent hashing data
-	// per Actor types.
+	// per Actor types. The key is built by EntityKey.
 	hashingTableMap map[string]*hashing.Consistent
+
+	// observers are notified of membership changes. They are runtime
+	// configuration and not persisted in snapshots.
+	observers []MembershipObserver
+
+	// maxEntityNameLength is the maximum length of the entity name.
+	// Zero means no limit.
+	maxEntityNameLength int
+	// maxEntitiesPerHost is the maximum number of entities which a host
+	// can report. Zero means no limit.
+	maxEntitiesPerHost int
+	// maxMembers is the maximum number of members. Zero means no limit.
+	maxMembers int
+	// frozen rejects all mutations of the members. See Freeze.
+	frozen bool
+	// hashFunc is the hash function used by all hashing tables, set by tests
+	// only. nil means the default hash function of hashing package.
+	hashFunc hashing.HashFunc
+	// replicationFactor is the number of virtual nodes per host of all hashing
+	// tables. Zero means the replication factor of hashing package.
+	replicationFactor int
+	// loadFactor bounds the load of a single host in ResolveActorHost as the
+	// factor of the average load. Zero disables bounded loads.
+	loadFactor float64
+	// metrics receives the counts of membership mutations.
+	metrics MutationMetrics
+	// rebalanceMetrics receives the rebalance magnitude. nil disables it.
+	rebalanceMetrics RebalanceMetrics
+	// nowFunc returns the current time for the timestamps of members and
+	// events. nil means time.Now in UTC.
+	nowFunc func() time.Time
+	// tombstoneGracePeriod is the duration for which a removed member is kept
+	// in the hashing tables. Zero removes members immediately.
+	tombstoneGracePeriod time.Duration
+	// minReplicas is the minimum number of hosts per hashing table key.
+	minReplicas map[string]int
+	// maxReplicas is the maximum number of hosts per hashing table key.
+	maxReplicas map[string]int
+	// allowedEntities is the Actor Types which hosts can report. nil allows
+	// any Actor Type.
+	allowedEntities map[string]struct{}
+	// strictAppID rejects upserts which change the AppID of an existing host.
+	strictAppID bool
+	// nameCollisionWindow rejects upserts which change the AppID of a host
+	// updated within the window. Zero means last writer wins.
+	nameCollisionWindow time.Duration
+	// generationWindow is the minimum number of applied raft log entries
+	// between the increases of TableGeneration. Zero increases it on every
+	// change.
+	generationWindow uint64
+	// lastGenerationIndex is Index at the last increase of TableGeneration.
+	lastGenerationIndex uint64
+	// generationPending is true if an increase of TableGeneration is deferred
+	// by generationWindow.
+	generationPending bool
+	// mutationValidator vetoes members before they are applied. nil accepts
+	// all members.
+	mutationValidator MutationValidator
+	// pendingRingOps is the ring operations since the last table generation.
+	pendingRingOps []ringOp
+	// tableHistory is the ring operations of the recent table generations.
+	tableHistory []tableChange
+	// hostSetGenerations is the table generation at which the distinct hosts
+	// of each hashing table last changed.
+	hostSetGenerations map[string]uint64
+	// events is the log of recent membership events. nil disables it.
+	events *eventLog
+	// ringsBefore is the rings of the keys changed since the last table
+	// generation, as they were before the changes.
+	ringsBefore map[string][]hashing.RingPoint
+	// onEntityAvailable is called when the hashing table of a key is created.
+	onEntityAvailable func(entity string)
+	// onEntityUnavailable is called when the last host leaves the hashing
+	// table of a key and the table is deleted.
+	onEntityUnavailable func(entity string)
+	// rebuildingTables suppresses the entity availability hooks while the
+	// hashing tables are rebuilt from Members.
+	rebuildingTables bool
+	// restoring rejects the mutations of the state while the hashing tables
+	// are rebuilt without the lock by restoreHashingTablesCtx, and after the
+	// rebuild is cancelled, so that Members stays in sync with the rebuilt
+	// tables.
+	restoring bool
+	// generationChanged is closed and reset when TableGeneration is
+	// increased, to wake up AwaitStable. nil means no one is waiting.
+	generationChanged chan struct{}
+	// entityFilterBits is the size of entityFilter. Zero disables it.
+	entityFilterBits int
+	// entityFilter holds the *entityFilter of the known keys, read without
+	// the lock. See SetEntityFilter.
+	entityFilter atomic.Value
+	// regionRings is the hashing tables of the hosts in each region per
+	// hashing table key, derived from hashingTableMap and the RegionLabel of
+	// the hosts. See ResolveActorHostInRegion.
+	regionRings map[string]map[string]*hashing.Consistent
+	// entityNames is the shared copy of each entity name reported by the
+	// members. See internEntity.
+	entityNames map[string]string
+	// entityNamesLive is the size of entityNames when it was last pruned.
+	entityNamesLive int
+}
+
+// EntityKey returns the key of the hashing table for the Actor Type in the
+// given namespace. The key is the Actor Type itself for the empty namespace.
+// upsertMember rejects '/' in namespaces and Actor Types, so that the key is
+// unambiguous.
+func EntityKey(namespace, entity string) string {
+	if namespace == "" {
+		return entity
+	}
+	return namespace + "/" + entity
+}
+
+// keyNamespace returns the namespace of the hashing table key built by
+// EntityKey.
+func keyNamespace(key string) string {
+	if i := strings.Index(key, "/"); i >= 0 {
+		return key[:i]
+	}
+	return ""
+}
+
+func (m *DaprHostMember) clone() *DaprHostMember {
+	n := &DaprHostMember{
+		Name:      m.Name,
+		AppID:     m.AppID,
+		Namespace: m.Namespace,
+		Entities:  make([]string, len(m.Entities)),
+		Labels:    copyLabels(m.Labels),
+		Draining:  m.Draining,
+		Weight:    m.Weight,
+		Version:   m.Version,
+		RequestID: m.RequestID,
+
+		EntityWeights: copyEntityWeights(m.EntityWeights),
+
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+		DeletedAt: m.DeletedAt,
+		PlacedAt:  m.PlacedAt,
+	}
+	copy(n.Entities, m.Entities)
+	if m.SkippedEntities != nil {
+		n.SkippedEntities = make([]string, len(m.SkippedEntities))
+		copy(n.SkippedEntities, m.SkippedEntities)
+	}
+	return n
+}
+
+// entityKeys returns the keys of the hashing tables which the host is in,
+// excluding the skipped ones.
+func (m *DaprHostMember) entityKeys() []string {
+	keys := make([]string, 0, len(m.Entities))
+	for _, e := range m.Entities {
+		key := EntityKey(m.Namespace, e)
+		if !m.skips(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// skips returns true if the host is not added to the hashing table of key
+// because of its maximum replicas.
+func (m *DaprHostMember) skips(key string) bool {
+	i := sort.SearchStrings(m.SkippedEntities, key)
+	return i < len(m.SkippedEntities) && m.SkippedEntities[i] == key
+}
+
+func copyEntityWeights(weights map[string]int) map[string]int {
+	if weights == nil {
+		return nil
+	}
+	n := make(map[string]int, len(weights))
+	for k, v := range weights {
+		n[k] = v
+	}
+	return n
+}
+
+// entityWeight returns the weight of the host in the hashing table of the
+// Actor Type.
+func (m *DaprHostMember) entityWeight(entity string) int {
+	if w, ok := m.EntityWeights[entity]; ok {
+		return w
+	}
+	return m.Weight
+}
+
+// sameHashingLayout returns true if both members place the same virtual
+// nodes in the same hashing tables.
+func sameHashingLayout(a, b *DaprHostMember) bool {
+	return a.Name == b.Name && a.Namespace == b.Namespace && a.Weight == b.Weight &&
+		cmp.Equal(a.Entities, b.Entities) &&
+		cmp.Equal(a.EntityWeights, b.EntityWeights, cmpopts.EquateEmpty())
+}
+
+// isReplayedUpsert returns true if the upsert of host carries the RequestID of
+// the last applied upsert of the member m.
+func isReplayedUpsert(m, host *DaprHostMember) bool {
+	return host.RequestID != "" && host.RequestID == m.RequestID
+}
+
+// withSortedEntities returns the host with its Entities sorted and the
+// duplicates removed, so that the stored Entities are canonical regardless of
+// the order reported by the host. The host itself is returned if its Entities
+// are already canonical, otherwise a shallow copy.
+func withSortedEntities(host *DaprHostMember) *DaprHostMember {
+	if isSortedUnique(host.Entities) {
+		return host
+	}
+
+	entities := make([]string, len(host.Entities))
+	copy(entities, host.Entities)
+	sort.Strings(entities)
+	unique := entities[:0]
+	for i, e := range entities {
+		if i == 0 || e != entities[i-1] {
+			unique = append(unique, e)
+		}
+	}
+
+	n := *host
+	n.Entities = unique
+	return &n
+}
+
+func isSortedUnique(entities []string) bool {
+	for i := 1; i < len(entities); i++ {
+		if entities[i-1] >= entities[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	n := make(map[string]string, len(labels))
+	for k, v := range labels {
+		n[k] = v
+	}
+	return n
 }
 
 func newDaprHostMemberState() *DaprHostMemberState {
 	return &DaprHostMemberState{
+		SchemaVersion:   SchemaVersion,
 		Index:           0,
 		TableGeneration: 0,
 		Members:         map[string]*DaprHostMember{},
 		hashingTableMap: map[string]*hashing.Consistent{},
+		events:          newEventLog(defaultEventLogSize),
 	}
 }
 
+// clone returns a deep copy of the persisted fields of the state. The hashing
+// tables are not copied and hashingTableMap is nil, so the copy can't resolve
+// actors until restoreHashingTables is called. This keeps the snapshot path
+// cheap, as the tables are rebuilt only when the snapshot is restored. Use
+// cloneWithTables for a copy which is usable immediately.
 func (s *DaprHostMemberState) clone() *DaprHostMemberState {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
 	newMembers := &DaprHostMemberState{
+		SchemaVersion:   s.SchemaVersion,
 		Index:           s.Index,
 		TableGeneration: s.TableGeneration,
 		Members:         map[string]*DaprHostMember{},
 		hashingTableMap: nil,
 	}
 	for k, v := range s.Members {
-		m := &DaprHostMember{
-			Name:      v.Name,
-			AppID:     v.AppID,
-			Entities:  make([]string, len(v.Entities)),
-			CreatedAt: v.CreatedAt,
-			UpdatedAt: v.UpdatedAt,
+		newMembers.Members[k] = v.clone()
+		newMembers.internEntities(newMembers.Members[k].Entities)
+	}
+	if s.NamespaceGenerations != nil {
+		newMembers.NamespaceGenerations = make(map[string]uint64, len(s.NamespaceGenerations))
+		for ns, g := range s.NamespaceGenerations {
+			newMembers.NamespaceGenerations[ns] = g
+		}
+	}
+	if s.EntityChangedAt != nil {
+		newMembers.EntityChangedAt = make(map[string]time.Time, len(s.EntityChangedAt))
+		for key, t := range s.EntityChangedAt {
+			newMembers.EntityChangedAt[key] = t
+		}
+	}
+	if s.Pins != nil {
+		newMembers.Pins = make(map[string]map[string]string, len(s.Pins))
+		for entity, pins := range s.Pins {
+			newMembers.Pins[entity] = make(map[string]string, len(pins))
+			for actorID, host := range pins {
+				newMembers.Pins[entity][actorID] = host
+			}
+		}
+	}
+	if s.OrphanedAt != nil {
+		newMembers.OrphanedAt = make(map[string]time.Time, len(s.OrphanedAt))
+		for key, t := range s.OrphanedAt {
+			newMembers.OrphanedAt[key] = t
+		}
+	}
+	if len(s.hostSetGenerations) > 0 {
+		newMembers.HostSetGenerations = make(map[string]uint64, len(s.hostSetGenerations))
+		for key, g := range s.hostSetGenerations {
+			newMembers.HostSetGenerations[key] = g
 		}
-		copy(m.Entities, v.Entities)
-		newMembers.Members[k] = m
 	}
+	if s.Aliases != nil {
+		newMembers.Aliases = make(map[string]string, len(s.Aliases))
+		for alias, entity := range s.Aliases {
+			newMembers.Aliases[alias] = entity
+		}
+	}
+	newMembers.GenerationPending = s.generationPending
+	newMembers.LastGenerationIndex = s.lastGenerationIndex
 	return newMembers
 }
 
-func (s *DaprHostMemberState) updateHashingTables(host *DaprHostMember) {
-	for _, e := range host.Entities {
-		if _, ok := s.hashingTableMap[e]; !ok {
-			s.hashingTableMap[e] = hashing.NewConsistentHash()
+// cloneWithTables returns a deep copy of the state including the hashing
+// tables rebuilt from the copied members, so that the copy is usable
+// immediately. The configuration of the hashing tables, such as the hash
+// function and the replication factor, is copied as well, but observers and
+// hooks are not.
+func (s *DaprHostMemberState) cloneWithTables() *DaprHostMemberState {
+	c := s.clone()
+
+	s.lock.RLock()
+	c.hashFunc = s.hashFunc
+	c.replicationFactor = s.replicationFactor
+	c.loadFactor = s.loadFactor
+	s.lock.RUnlock()
+
+	c.restoreHashingTables()
+	return c
+}
+
+// migrateState upgrades the state deserialized from an older schema version
+// to SchemaVersion. It returns an error for unknown future versions.
+func migrateState(s *DaprHostMemberState) error {
+	if s.SchemaVersion > SchemaVersion {
+		return errors.Errorf("unsupported placement state schema version %d, the latest supported version is %d",
+			s.SchemaVersion, SchemaVersion)
+	}
+
+	if s.SchemaVersion == 0 {
+		// unversioned state has no Namespace, Labels and Weight. Their zero
+		// values preserve the original behavior, so nothing to convert.
+		s.SchemaVersion = 1
+	}
+
+	if s.SchemaVersion == 1 {
+		// version 1 has no Pins, Aliases, EntityChangedAt, HostSetGenerations,
+		// OrphanedAt, the generation window checkpoint and PlacedAt, whose
+		// zero values mean unknown. The namespaces start at TableGeneration
+		// instead of zero, so that their generations don't go back.
+		if s.NamespaceGenerations == nil && s.TableGeneration > 0 {
+			s.NamespaceGenerations = map[string]uint64{}
+			for _, m := range s.Members {
+				s.NamespaceGenerations[m.Namespace] = s.TableGeneration
+			}
 		}
+		s.SchemaVersion = 2
+	}
+
+	return nil
+}
 
-		s.hashingTableMap[e].Add(host.Name, host.AppID, 0)
+// copyRuntimeConfig copies the runtime configuration which is not persisted
+// in snapshots, such as observers, from the other state.
+func (s *DaprHostMemberState) copyRuntimeConfig(other *DaprHostMemberState) {
+	other.lock.RLock()
+	defer other.lock.RUnlock()
+
+	s.observers = other.observers
+	s.maxEntityNameLength = other.maxEntityNameLength
+	s.maxEntitiesPerHost = other.maxEntitiesPerHost
+	s.maxMembers = other.maxMembers
+	s.frozen = other.frozen
+	s.hashFunc = other.hashFunc
+	s.replicationFactor = other.replicationFactor
+	s.loadFactor = other.loadFactor
+	s.metrics = other.metrics
+	s.rebalanceMetrics = other.rebalanceMetrics
+	s.nowFunc = other.nowFunc
+	s.tombstoneGracePeriod = other.tombstoneGracePeriod
+	s.minReplicas = other.minReplicas
+	s.maxReplicas = other.maxReplicas
+	s.strictAppID = other.strictAppID
+	s.nameCollisionWindow = other.nameCollisionWindow
+	s.mutationValidator = other.mutationValidator
+	s.generationWindow = other.generationWindow
+	s.entityFilterBits = other.entityFilterBits
+	s.allowedEntities = other.allowedEntities
+	s.events = other.events
+	s.onEntityAvailable = other.onEntityAvailable
+	s.onEntityUnavailable = other.onEntityUnavailable
+}
+
+// SetClock sets the function which returns the current time for the
+// timestamps of members and events, so that tests can control the clock.
+// nil restores time.Now in UTC.
+func (s *DaprHostMemberState) SetClock(now func() time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.nowFunc = now
+}
+
+// now returns the current time of the clock. See SetClock.
+func (s *DaprHostMemberState) now() time.Time {
+	if s.nowFunc == nil {
+		return time.Now().UTC()
 	}
+	return s.nowFunc()
 }
 
-func (s *DaprHostMemberState) removeHashingTables(host *DaprHostMember) {
-	for _, e := range host.Entities {
-		if t, ok := s.hashingTableMap[e]; ok {
-			t.Remove(host.Name)
+// SetTombstoneGracePeriod enables soft removal of members. removeMember marks
+// the member as deleted and keeps it in the hashing tables for the grace
+// period, so that a flapping host which reconnects within the period causes
+// no rebalancing. Zero disables tombstones.
+func (s *DaprHostMemberState) SetTombstoneGracePeriod(d time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-			// if no dedicated actor service instance for the particular actor type,
-			// we must delete consistent hashing table to avoid the memory leak.
-			if len(t.Hosts()) == 0 {
-				delete(s.hashingTableMap, e)
-			}
+	s.tombstoneGracePeriod = d
+}
+
+// SetReplicationFactor sets the number of virtual nodes per host of all
+// hashing tables. Existing tables are not rebuilt and the placement tables
+// of all placement servers must agree, so this must be set at cluster init.
+// Zero uses the replication factor of hashing package.
+func (s *DaprHostMemberState) SetReplicationFactor(replicas int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.replicationFactor = replicas
+}
+
+// SetBoundedLoads enables consistent hashing with bounded loads in
+// ResolveActorHost. The load of a single host is bounded by the factor of the
+// average load, and the actors over the bound go to the next host clockwise.
+// The loads are reported by SetHostLoad. Existing tables keep their factor,
+// so this must be set before any member is added. Zero disables bounded loads,
+// and it returns an error for any other factor not greater than one.
+func (s *DaprHostMemberState) SetBoundedLoads(factor float64) error {
+	if factor != 0 && !(factor > 1) {
+		return errors.Errorf("invalid load factor %v, it must be zero or greater than one", factor)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.loadFactor = factor
+	return nil
+}
+
+// SetHostLoad sets the load of the host in the hashing table of the key.
+// It returns false if the host is not in the table.
+func (s *DaprHostMemberState) SetHostLoad(entity, host string, load int64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return false
+	}
+	if _, _, loadMap, _ := t.GetInternals(); loadMap[host] == nil {
+		return false
+	}
+	t.UpdateLoad(host, load)
+	return true
+}
+
+// hashingTablePool reuses the hashing tables which become empty, to reduce
+// allocations when entities are frequently added and removed.
+var hashingTablePool sync.Pool
+
+// newHashingTable returns a hashing table with the options of the state,
+// reusing an emptied table if available.
+func (s *DaprHostMemberState) newHashingTable() *hashing.Consistent {
+	opts := []hashing.Option{
+		hashing.WithHashFunc(s.hashFunc),
+		hashing.WithReplicationFactor(s.replicationFactor),
+		hashing.WithLoadFactor(s.loadFactor),
+	}
+	if t, ok := hashingTablePool.Get().(*hashing.Consistent); ok {
+		t.Reset(opts...)
+		return t
+	}
+	return hashing.NewConsistentHash(opts...)
+}
+
+// SetMaxReplicas sets the maximum number of hosts per hashing table key.
+// upsertMember doesn't add a host to a table which is at its maximum and
+// records the key in SkippedEntities of the host instead. A host skipped
+// once joins the table only when it changes or is undrained.
+func (s *DaprHostMemberState) SetMaxReplicas(maxReplicas map[string]int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.maxReplicas = make(map[string]int, len(maxReplicas))
+	for k, v := range maxReplicas {
+		s.maxReplicas[k] = v
+	}
+}
+
+// skippedEntities returns the sorted hashing table keys of the host which are
+// at their maximum replicas. servedKeys are the keys which the host is already
+// removed from but still counted in. The caller must hold the lock.
+func (s *DaprHostMemberState) skippedEntities(host *DaprHostMember, servedKeys map[string]struct{}) []string {
+	var skipped []string
+	for _, e := range host.Entities {
+		key := EntityKey(host.Namespace, e)
+		limit, ok := s.maxReplicas[key]
+		if !ok || limit <= 0 {
+			continue
+		}
+		count := s.entityHostCount(key)
+		if _, ok := servedKeys[key]; ok {
+			count--
+		}
+		if count >= limit {
+			skipped = append(skipped, key)
 		}
 	}
+	sort.Strings(skipped)
+	return skipped
 }
 
-func (s *DaprHostMemberState) upsertMember(host *DaprHostMember) bool {
-	now := time.Now().UTC()
-	tableUpdateRequired := false
+// SetAllowedEntities sets the Actor Types which upsertMember accepts. A host
+// reporting any other Actor Type is rejected. nil allows any Actor Type.
+func (s *DaprHostMemberState) SetAllowedEntities(entities map[string]struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 
-	if m, ok := s.Members[host.Name]; ok {
-		if m.AppID == host.AppID && m.Name == host.Name && cmp.Equal(m.Entities, host.Entities) {
-			m.UpdatedAt = now
-			return false
+	if entities == nil {
+		s.allowedEntities = nil
+		return
+	}
+	s.allowedEntities = make(map[string]struct{}, len(entities))
+	for e := range entities {
+		s.allowedEntities[e] = struct{}{}
+	}
+}
+
+// SetStrictAppID sets whether upsertMember rejects an update which changes
+// the AppID of an existing host. The AppID can be changed by default.
+func (s *DaprHostMemberState) SetStrictAppID(strict bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.strictAppID = strict
+}
+
+// SetNameCollisionWindow sets the window in which the name of a host stays
+// reserved for its AppID. upsertMember of the name with a different AppID
+// returns an error caused by ErrNameCollision while the existing host was
+// updated within the window, since two hosts likely registered the same name.
+// Once the existing host is older than the window, or is tombstoned, the
+// upsert overwrites it. Zero disables the check, so that the last writer
+// wins, which is the default.
+func (s *DaprHostMemberState) SetNameCollisionWindow(window time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nameCollisionWindow = window
+}
+
+// SetMutationValidator sets the validator which can veto members, such as to
+// reject hosts missing a required label. It is called with the member, whose
+// Entities are sorted, before the built-in validation of upsertMember,
+// upsertMembers and the other mutations which change the Actor Types of a
+// member. If it returns an error, the mutation is rejected with the error as
+// its cause and the state is left unchanged. Like observers, it is called
+// while the state lock is held and must neither modify the member nor call
+// back into the state. nil removes the validator.
+func (s *DaprHostMemberState) SetMutationValidator(validator MutationValidator) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.mutationValidator = validator
+}
+
+// SetEntityLimits sets the maximum length of entity names and the maximum
+// number of entities per host accepted by upsertMember. Zero means no limit.
+func (s *DaprHostMemberState) SetEntityLimits(maxNameLength, maxEntitiesPerHost int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.maxEntityNameLength = maxNameLength
+	s.maxEntitiesPerHost = maxEntitiesPerHost
+}
+
+// Freeze makes the members read-only for maintenance, so that operators can
+// inspect a stable state. upsertMember and removeMember return ErrFrozen and
+// the other mutations of the members are no-ops until Unfreeze is called.
+// Reads are not affected. The leader rejects member commands before they are
+// proposed while frozen, but committed raft logs are still applied, so that
+// this state doesn't diverge from its peers.
+func (s *DaprHostMemberState) Freeze() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.frozen = true
+}
+
+// Unfreeze allows the mutations of the members again. See Freeze.
+func (s *DaprHostMemberState) Unfreeze() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.frozen = false
+}
+
+// Frozen returns true while the state is frozen. See Freeze.
+func (s *DaprHostMemberState) Frozen() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.frozen
+}
+
+// SetMaxMembers sets the maximum number of members. upsertMember of a new
+// host beyond the maximum returns an error caused by ErrMaxMembers, while
+// updates of existing members still succeed. Zero means no limit.
+func (s *DaprHostMemberState) SetMaxMembers(maxMembers int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.maxMembers = maxMembers
+}
+
+// checkMaxMembers returns an error caused by ErrMaxMembers if adding the
+// number of new members exceeds the maximum. The caller must hold the lock.
+func (s *DaprHostMemberState) checkMaxMembers(newMembers int) error {
+	if s.maxMembers > 0 && newMembers > 0 && len(s.Members)+newMembers > s.maxMembers {
+		return errors.Wrapf(ErrMaxMembers, "cannot add %d members to %d members with the limit of %d",
+			newMembers, len(s.Members), s.maxMembers)
+	}
+	return nil
+}
+
+// validateMember returns an error if the mutation validator rejects the
+// member or the member reports malformed entities.
+func (s *DaprHostMemberState) validateMember(host *DaprHostMember) error {
+	if s.mutationValidator != nil {
+		if err := s.mutationValidator(host); err != nil {
+			return errors.Wrapf(err, "host %s is rejected by the mutation validator", host.Name)
 		}
-		if s.isActorHost(m) {
-			s.removeHashingTables(m)
-			tableUpdateRequired = true
+	}
+
+	m, ok := s.Members[host.Name]
+	if ok && s.strictAppID && m.AppID != host.AppID {
+		return errors.Errorf("host %s cannot change app id from %s to %s",
+			host.Name, m.AppID, host.AppID)
+	}
+	if !ok {
+		if err := s.checkMaxMembers(1); err != nil {
+			return err
 		}
 	}
 
-	s.Members[host.Name] = &DaprHostMember{
-		Name:  host.Name,
-		AppID: host.AppID,
+	// the separator of the hashing table keys, see EntityKey.
+	if strings.Contains(host.Namespace, "/") {
+		return errors.Errorf("host %s reports namespace %q containing '/'", host.Name, host.Namespace)
+	}
 
-		CreatedAt: now,
-		UpdatedAt: now,
+	if s.maxEntitiesPerHost > 0 && len(host.Entities) > s.maxEntitiesPerHost {
+		return errors.Errorf("host %s reports %d actor types, exceeding the limit of %d",
+			host.Name, len(host.Entities), s.maxEntitiesPerHost)
 	}
 
-	// update hashing table only when host reports actor types
-	if s.isActorHost(host) {
-		s.Members[host.Name].Entities = make([]string, len(host.Entities))
-		copy(s.Members[host.Name].Entities, host.Entities)
+	for _, e := range host.Entities {
+		if strings.TrimSpace(e) == "" {
+			return errors.Errorf("host %s reports an empty actor type", host.Name)
+		}
+		if strings.Contains(e, "/") {
+			return errors.Errorf("host %s reports actor type %q containing '/'", host.Name, e)
+		}
+		if _, ok := s.allowedEntities[e]; s.allowedEntities != nil && !ok {
+			return errors.Errorf("host %s reports actor type %q which is not allowed", host.Name, e)
+		}
+		if s.maxEntityNameLength > 0 && len(e) > s.maxEntityNameLength {
+			return errors.Errorf("host %s reports actor type %q exceeding the name length limit of %d",
+				host.Name, e, s.maxEntityNameLength)
+		}
+	}
 
-		s.updateHashingTables(s.Members[host.Name])
-		tableUpdateRequired = true
+	return nil
+}
+
+// CheckNameCollision returns an error caused by ErrNameCollision if the host
+// has the name of an existing member with another AppID, which was updated
+// within the name collision window. See SetNameCollisionWindow. The check
+// reads the clock, so the leader runs it before proposing the upsert instead
+// of the FSM applying the committed log.
+func (s *DaprHostMemberState) CheckNameCollision(host *DaprHostMember) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.checkNameCollision(host)
+}
+
+// checkNameCollision is CheckNameCollision. The caller must hold the lock.
+func (s *DaprHostMemberState) checkNameCollision(host *DaprHostMember) error {
+	m, ok := s.Members[host.Name]
+	if !ok || !s.isNameCollision(m, host) {
+		return nil
 	}
+	return errors.Wrapf(ErrNameCollision, "host %s is registered by app id %s, rejecting app id %s",
+		host.Name, m.AppID, host.AppID)
+}
 
-	if tableUpdateRequired {
-		s.TableGeneration++
+// isNameCollision returns true if the host has the name of the existing
+// member m, but a different AppID, while m is recently updated and not
+// tombstoned. The caller must hold the lock.
+func (s *DaprHostMemberState) isNameCollision(m, host *DaprHostMember) bool {
+	if s.nameCollisionWindow <= 0 || m.AppID == host.AppID || !m.DeletedAt.IsZero() {
+		return false
 	}
+	return s.now().Sub(m.UpdatedAt) < s.nameCollisionWindow
+}
 
-	return tableUpdateRequired
+// incTableGeneration increases TableGeneration and notifies observers. Within
+// the generation window since the last increase, the increase is deferred
+// until the window closes, accumulating the ring operations in the meantime.
+// See SetGenerationWindow.
+func (s *DaprHostMemberState) incTableGeneration() {
+	// the region rings are read along with the hashing tables, so they are
+	// refreshed even if the increase is deferred.
+	s.refreshRegionRings(ringOpKeys(s.pendingRingOps))
+
+	// the window opens at an increase, so the first increase is immediate.
+	if s.generationWindow > 0 && s.TableGeneration > 0 && s.Index < s.lastGenerationIndex+s.generationWindow {
+		s.generationPending = true
+		return
+	}
+	s.applyTableGeneration()
 }
 
-func (s *DaprHostMemberState) removeMember(host *DaprHostMember) bool {
-	tableUpdateRequired := false
-	if m, ok := s.Members[host.Name]; ok {
-		if s.isActorHost(m) {
-			s.removeHashingTables(m)
-			s.TableGeneration++
-			tableUpdateRequired = true
+// applyTableGeneration increases TableGeneration once for all ring operations
+// since the last increase. The caller must hold the write lock.
+func (s *DaprHostMemberState) applyTableGeneration() {
+	s.TableGeneration++
+	s.commitRingOps()
+	s.rebuildEntityFilter()
+	s.recordTableGeneration()
+	s.notifyTableGeneration()
+
+	s.lastGenerationIndex = s.Index
+	s.generationPending = false
+}
+
+// flushPendingGeneration applies the pending increase of TableGeneration, if
+// any, so that the ring operations so far are committed. The caller must hold
+// the write lock.
+func (s *DaprHostMemberState) flushPendingGeneration() bool {
+	if !s.generationPending {
+		return false
+	}
+	s.applyTableGeneration()
+	return true
+}
+
+// flushPending increases TableGeneration for the changes of the hashing
+// tables deferred by the generation window, without waiting for the window
+// to close. It returns false if no increase is pending. It is applied by the
+// TableGenerationFlush command, so that all replicas flush at the same log
+// entry.
+func (s *DaprHostMemberState) flushPending() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.flushPendingGeneration()
+}
+
+// PendingGeneration returns true if an increase of TableGeneration is
+// deferred by the generation window. The leader proposes TableGenerationFlush
+// before disseminating the tables in that case, so that the version of the
+// disseminated tables changes with them.
+func (s *DaprHostMemberState) PendingGeneration() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.generationPending
+}
+
+// SetGenerationWindow sets the minimum number of applied raft log entries
+// between the increases of TableGeneration, to debounce the dissemination of
+// rapidly flapping members. The first change of the hashing tables increases
+// TableGeneration immediately, and the changes within the window after it are
+// coalesced into a single increase, applied by the first log entry after the
+// window or by a TableGenerationFlush command. The hashing tables always
+// reflect the latest changes, only TableGeneration, the table deltas and
+// observers lag behind. The window is counted in log entries rather than
+// time, so that TableGeneration stays a function of the raft log, and it
+// must be set to the same value on every replica. Zero disables the window,
+// and a pending increase is applied by the next log entry.
+func (s *DaprHostMemberState) SetGenerationWindow(window uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.generationWindow = window
+}
+
+// updateHashingTables adds the host to the hashing tables of its entities.
+// The caller must hold the write lock.
+func (s *DaprHostMemberState) updateHashingTables(host *DaprHostMember) {
+	for _, e := range host.Entities {
+		if key := EntityKey(host.Namespace, e); !host.skips(key) {
+			s.addToHashingTable(key, host, host.entityWeight(e))
 		}
-		delete(s.Members, host.Name)
 	}
+}
 
-	return tableUpdateRequired
+// removeHashingTables removes the host from the hashing tables of its entities.
+// The caller must hold the write lock.
+func (s *DaprHostMemberState) removeHashingTables(host *DaprHostMember) {
+	for _, key := range host.entityKeys() {
+		s.removeFromHashingTable(key, host)
+	}
 }
 
-func (s *DaprHostMemberState) isActorHost(host *DaprHostMember) bool {
-	return len(host.Entities) > 0
+// updateHashingTablesAppID sets the app id of the host in its hashing tables
+// and returns the keys of the updated tables. The host is recorded as both
+// removed from and added to the updated tables, like a weight change, so that
+// the deltas and the generations reflect the new app id. The caller must hold
+// the write lock.
+func (s *DaprHostMemberState) updateHashingTablesAppID(host *DaprHostMember, appID string) []string {
+	updated := s.tablesWithHost(host)
+	for key := range updated {
+		s.hashingTableMap[key].UpdateAppID(host.Name, appID)
+		s.recordRingOp(key, host.Namespace, host.Name, false)
+		s.recordRingOp(key, host.Namespace, host.Name, true)
+	}
+	return sortedKeys(updated)
 }
 
-func (s *DaprHostMemberState) restoreHashingTables() {
-	if s.hashingTableMap == nil {
-		s.hashingTableMap = map[string]*hashing.Consistent{}
+// tablesWithHost returns the keys of the hashing tables which contain the
+// host. The caller must hold the lock.
+func (s *DaprHostMemberState) tablesWithHost(host *DaprHostMember) map[string]struct{} {
+	keys := map[string]struct{}{}
+	if !s.servesHashingTables(host) {
+		return keys
 	}
+	for _, key := range host.entityKeys() {
+		if t, ok := s.hashingTableMap[key]; ok && t.Contains(host.Name) {
+			keys[key] = struct{}{}
+		}
+	}
+	return keys
+}
 
-	for _, m := range s.Members {
-		s.updateHashingTables(m)
+// addToHashingTable adds the host with the weight to the hashing table of
+// the key and creates the table if it doesn't exist.
+func (s *DaprHostMemberState) addToHashingTable(key string, host *DaprHostMember, weight int) {
+	s.snapshotRing(key)
+	if _, ok := s.hashingTableMap[key]; !ok {
+		s.hashingTableMap[key] = s.newHashingTable()
+		s.addToEntityFilter(key)
+		s.notifyEntityAvailable(key)
+	}
+
+	if !s.hashingTableMap[key].AddWithWeight(host.Name, host.AppID, 0, weight) {
+		s.recordRingOp(key, host.Namespace, host.Name, true)
+		s.markPlaced(host)
+	}
+}
+
+// removeFromHashingTable removes the host from the hashing table of the key.
+func (s *DaprHostMemberState) removeFromHashingTable(key string, host *DaprHostMember) {
+	if t, ok := s.hashingTableMap[key]; ok {
+		s.snapshotRing(key)
+		t.Remove(host.Name)
+		s.recordRingOp(key, host.Namespace, host.Name, false)
+
+		// if no dedicated actor service instance for the particular actor type,
+		// we must delete consistent hashing table to avoid the memory leak.
+		if t.HostCount() == 0 {
+			delete(s.hashingTableMap, key)
+			hashingTablePool.Put(t)
+			s.notifyEntityUnavailable(key)
+		}
+	}
+}
+
+// UpsertReason is the reason why an upsert did or didn't update the hashing
+// tables.
+type UpsertReason string
+
+const (
+	// UpsertReasonNewHost is a new host which reports Actor Types.
+	UpsertReasonNewHost UpsertReason = "new_host"
+	// UpsertReasonNonActorHost is a host which reports no Actor Types, before
+	// and after the upsert.
+	UpsertReasonNonActorHost UpsertReason = "non_actor_host"
+	// UpsertReasonAppIDChanged is an existing host whose AppID changed. The
+	// hosts of the hashing tables are kept, but the tables carrying the host
+	// are updated with the new AppID.
+	UpsertReasonAppIDChanged UpsertReason = "app_id_changed"
+	// UpsertReasonEntitiesChanged is an existing host whose Actor Types,
+	// namespace or weights changed.
+	UpsertReasonEntitiesChanged UpsertReason = "entities_changed"
+	// UpsertReasonUnchanged is an existing host whose hashing layout and AppID
+	// are unchanged, such as a heartbeat or a label only change.
+	UpsertReasonUnchanged UpsertReason = "unchanged"
+	// UpsertReasonReplayed is a retry of the upsert with the same RequestID.
+	UpsertReasonReplayed UpsertReason = "replayed"
+)
+
+// UpsertResult is the outcome of an upsert.
+type UpsertResult struct {
+	// Reason is why the hashing tables are or aren't updated.
+	Reason UpsertReason
+	// Entities is the sorted hashing table keys whose tables are added to or
+	// removed from.
+	Entities []string
+}
+
+// TableChanged returns true if any hashing table is updated.
+func (r UpsertResult) TableChanged() bool {
+	return len(r.Entities) > 0
+}
+
+// upsertMember updates or inserts the member. It returns true if any hashing
+// table is updated. The state is left unchanged if the member is invalid.
+func (s *DaprHostMemberState) upsertMember(host *DaprHostMember) (bool, error) {
+	_, changed, err := s.upsertMemberWithEntities(host)
+	return changed, err
+}
+
+// upsertMemberWithEntities updates or inserts the member and returns the
+// sorted hashing table keys whose tables are added to or removed from.
+func (s *DaprHostMemberState) upsertMemberWithEntities(host *DaprHostMember) (changedEntities []string, changed bool, err error) {
+	result, err := s.upsertMemberResult(host)
+	return result.Entities, result.TableChanged(), err
+}
+
+// upsertMemberResult updates or inserts the member like upsertMember, and
+// returns why the hashing tables are or aren't updated along with the keys of
+// the updated tables. The result of an invalid member has no Reason.
+func (s *DaprHostMemberState) upsertMemberResult(host *DaprHostMember) (UpsertResult, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.validateAndUpsert(host)
+}
+
+// upsertMemberWithSkipped upserts the member and returns the sorted hashing
+// table keys which the member is not added to because of their maximum
+// replicas. See SetMaxReplicas.
+func (s *DaprHostMemberState) upsertMemberWithSkipped(host *DaprHostMember) (skipped []string, changed bool, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result, err := s.validateAndUpsert(host)
+	if err != nil {
+		return []string{}, false, err
+	}
+
+	skipped = []string{}
+	if m, ok := s.Members[host.Name]; ok {
+		skipped = append(skipped, m.SkippedEntities...)
+	}
+	return skipped, result.TableChanged(), nil
+}
+
+// checkMutable returns ErrFrozen while the state is frozen and ErrRestoring
+// while the hashing tables are restored. The caller must hold the lock.
+func (s *DaprHostMemberState) checkMutable() error {
+	if s.frozen {
+		return ErrFrozen
+	}
+	if s.restoring {
+		return ErrRestoring
+	}
+	return nil
+}
+
+// validateAndUpsert validates and upserts the member, and increases
+// TableGeneration if any hashing table is updated. The caller must hold the
+// write lock.
+func (s *DaprHostMemberState) validateAndUpsert(host *DaprHostMember) (UpsertResult, error) {
+	if err := s.checkMutable(); err != nil {
+		return UpsertResult{Entities: []string{}}, err
+	}
+	if err := s.checkNameCollision(host); err != nil {
+		return UpsertResult{Entities: []string{}}, err
+	}
+	return s.validateAndUpsertUnfrozen(host)
+}
+
+// validateAndUpsertUnfrozen is validateAndUpsert without the mutability and
+// name collision checks, which must not depend on local conditions when a
+// committed raft log is applied. The caller must hold the write lock.
+func (s *DaprHostMemberState) validateAndUpsertUnfrozen(host *DaprHostMember) (UpsertResult, error) {
+	host = withSortedEntities(host)
+	if err := s.validateMember(host); err != nil {
+		return UpsertResult{Entities: []string{}}, err
+	}
+
+	result := s.applyMemberUpsert(host, s.now())
+	if result.TableChanged() {
+		s.incTableGeneration()
+	}
+
+	return result, nil
+}
+
+// previewUpsert returns whether upsertMember would update any hashing table
+// for the host and the sorted keys of the hashing tables it would update,
+// without changing the state. It returns the error upsertMember would return
+// if the host is rejected.
+func (s *DaprHostMemberState) previewUpsert(host *DaprHostMember) (wouldChange bool, affectedEntities []string, err error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if err := s.checkMutable(); err != nil {
+		return false, []string{}, err
+	}
+	host = withSortedEntities(host)
+	if err := s.checkNameCollision(host); err != nil {
+		return false, []string{}, err
+	}
+	if err := s.validateMember(host); err != nil {
+		return false, []string{}, err
+	}
+
+	changed := map[string]struct{}{}
+	served := map[string]struct{}{}
+	draining := false
+	if m, ok := s.Members[host.Name]; ok {
+		// label only change doesn't update hashing tables, and app id only
+		// change updates the app id in the tables carrying the host.
+		if isReplayedUpsert(m, host) || (m.AppID == host.AppID && sameHashingLayout(m, host)) {
+			return false, []string{}, nil
+		}
+		if sameHashingLayout(m, host) {
+			affectedEntities = sortedKeys(s.tablesWithHost(m))
+			return len(affectedEntities) > 0, affectedEntities, nil
+		}
+		if s.servesHashingTables(m) {
+			addEntityKeys(served, m)
+			addEntityKeys(changed, m)
+		}
+		draining = m.Draining
+	}
+	if s.isActorHost(host) && !draining {
+		n := host.clone()
+		n.SkippedEntities = s.skippedEntities(host, served)
+		addEntityKeys(changed, n)
+	}
+
+	affectedEntities = sortedKeys(changed)
+	return len(affectedEntities) > 0, affectedEntities, nil
+}
+
+// upsertMembers upserts multiple members at once. TableGeneration is
+// increased at most once for the whole batch. No member is applied if any
+// member in the batch is invalid.
+func (s *DaprHostMemberState) upsertMembers(hosts []*DaprHostMember) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.checkMutable(); err != nil {
+		return false, err
+	}
+
+	unique := make([]*DaprHostMember, len(hosts))
+	added := map[string]struct{}{}
+	for i, host := range hosts {
+		unique[i] = withSortedEntities(host)
+		if err := s.checkNameCollision(unique[i]); err != nil {
+			return false, err
+		}
+		if err := s.validateMember(unique[i]); err != nil {
+			return false, err
+		}
+		if _, ok := s.Members[host.Name]; !ok {
+			added[host.Name] = struct{}{}
+		}
+	}
+	if err := s.checkMaxMembers(len(added)); err != nil {
+		return false, err
+	}
+
+	now := s.now()
+	tableUpdateRequired := false
+
+	for _, host := range unique {
+		if s.applyMemberUpsert(host, now).TableChanged() {
+			tableUpdateRequired = true
+		}
+	}
+
+	if tableUpdateRequired {
+		s.incTableGeneration()
+	}
+
+	return tableUpdateRequired, nil
+}
+
+// applyMemberUpsert upserts the member and updates hashing tables without
+// increasing TableGeneration. The caller must hold the write lock.
+func (s *DaprHostMemberState) applyMemberUpsert(host *DaprHostMember, now time.Time) UpsertResult {
+	changed := map[string]struct{}{}
+
+	reason := UpsertReasonNewHost
+	draining := false
+	createdAt := now
+	var placedAt time.Time
+	version := uint64(1)
+	if m, ok := s.Members[host.Name]; ok {
+		if isReplayedUpsert(m, host) {
+			return UpsertResult{Reason: UpsertReasonReplayed, Entities: []string{}}
+		}
+		m.RequestID = host.RequestID
+		if m.AppID == host.AppID && sameHashingLayout(m, host) {
+			// label only change doesn't require hashing table updates.
+			if !cmp.Equal(m.Labels, host.Labels, cmpopts.EquateEmpty()) {
+				regionChanged := m.Labels[RegionLabel] != host.Labels[RegionLabel]
+				m.Labels = copyLabels(host.Labels)
+				if regionChanged && s.servesHashingTables(m) {
+					s.refreshMemberRegionRings(m)
+				}
+				m.Version++
+				s.recordEvent(MembershipEventAdd, m)
+				s.notifyMemberAdded(m)
+			}
+			// reconnecting host cancels its tombstone without table changes.
+			m.DeletedAt = time.Time{}
+			m.UpdatedAt = now
+			s.recordUpsert(true)
+			return UpsertResult{Reason: UpsertReasonUnchanged, Entities: []string{}}
+		}
+		if sameHashingLayout(m, host) {
+			// app id only change keeps the hosts of the hashing tables.
+			regionChanged := m.Labels[RegionLabel] != host.Labels[RegionLabel]
+			updated := s.updateHashingTablesAppID(m, host.AppID)
+			m.AppID = host.AppID
+			m.Labels = copyLabels(host.Labels)
+			if regionChanged && s.servesHashingTables(m) {
+				s.refreshMemberRegionRings(m)
+			}
+			m.Version++
+			m.DeletedAt = time.Time{}
+			m.UpdatedAt = now
+			s.recordUpsert(false)
+			s.recordEvent(MembershipEventAdd, m)
+			s.notifyMemberAdded(m)
+			// the tables carry the app ids of the hosts, so that they are
+			// changed even though the rings are the same.
+			return UpsertResult{Reason: UpsertReasonAppIDChanged, Entities: updated}
+		}
+		reason = UpsertReasonEntitiesChanged
+		if !s.isActorHost(m) && !s.isActorHost(host) {
+			reason = UpsertReasonNonActorHost
+		}
+		if s.servesHashingTables(m) {
+			s.removeHashingTables(m)
+			addEntityKeys(changed, m)
+		}
+		// draining host must stay out of hashing tables until it is undrained.
+		draining = m.Draining
+		createdAt = m.CreatedAt
+		placedAt = m.PlacedAt
+		version = m.Version + 1
+	}
+
+	s.Members[host.Name] = &DaprHostMember{
+		Name:      host.Name,
+		AppID:     host.AppID,
+		Namespace: host.Namespace,
+		Labels:    copyLabels(host.Labels),
+		Draining:  draining,
+		Weight:    host.Weight,
+		Version:   version,
+		RequestID: host.RequestID,
+
+		EntityWeights: copyEntityWeights(host.EntityWeights),
+
+		CreatedAt: createdAt,
+		UpdatedAt: now,
+		PlacedAt:  placedAt,
+	}
+
+	// update hashing table only when host reports actor types
+	if s.isActorHost(host) {
+		s.Members[host.Name].Entities = make([]string, len(host.Entities))
+		copy(s.Members[host.Name].Entities, host.Entities)
+		s.internEntities(s.Members[host.Name].Entities)
+
+		if !draining {
+			s.Members[host.Name].SkippedEntities = s.skippedEntities(host, nil)
+			s.updateHashingTables(s.Members[host.Name])
+			addEntityKeys(changed, s.Members[host.Name])
+		}
+	}
+
+	if reason == UpsertReasonNewHost && !s.isActorHost(host) {
+		reason = UpsertReasonNonActorHost
+	}
+
+	s.recordUpsert(false)
+	s.recordEvent(MembershipEventAdd, s.Members[host.Name])
+	s.notifyMemberAdded(s.Members[host.Name])
+
+	return UpsertResult{Reason: reason, Entities: sortedKeys(changed)}
+}
+
+// addEntityKeys adds the hashing table keys of the host to keys.
+func addEntityKeys(keys map[string]struct{}, host *DaprHostMember) {
+	for _, key := range host.entityKeys() {
+		keys[key] = struct{}{}
+	}
+}
+
+func sortedKeys(keys map[string]struct{}) []string {
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// removeMember removes the member. It returns true if any hashing table is
+// updated, and ErrFrozen while the state is frozen or ErrRestoring while it
+// is restored.
+func (s *DaprHostMemberState) removeMember(host *DaprHostMember) (bool, error) {
+	_, tableUpdateRequired, err := s.removeMemberChecked(host)
+	return tableUpdateRequired, err
+}
+
+// removeMemberChecked removes the member. existed is false when the member
+// is unknown, so that callers can distinguish it from a non actor host.
+func (s *DaprHostMemberState) removeMemberChecked(host *DaprHostMember) (existed bool, tableChanged bool, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.checkMutable(); err != nil {
+		return false, false, err
+	}
+
+	existed, _, tableChanged = s.applyMemberRemove(host)
+	if tableChanged {
+		s.incTableGeneration()
+	}
+
+	return existed, tableChanged, nil
+}
+
+// applyCommitted runs the mutation of a committed raft log. Committed logs
+// are applied even while the state is frozen, since dropping them would make
+// this state diverge from its peers, but not while the hashing tables are
+// restored.
+func (s *DaprHostMemberState) applyCommitted(fn func() error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.restoring {
+		return ErrRestoring
+	}
+	return fn()
+}
+
+// removeMemberWithDelta removes the member and returns, per hashing table key,
+// the hosts which newly cover the virtual nodes vacated by the removed host.
+// The list is empty when the removed host was the last host of the table.
+func (s *DaprHostMemberState) removeMemberWithDelta(host *DaprHostMember) (moved map[string][]string, changed bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return map[string][]string{}, false
+	}
+
+	_, moved, changed = s.applyMemberRemove(host)
+	if changed {
+		s.incTableGeneration()
+	}
+
+	return moved, changed
+}
+
+// planRemoval returns, per hashing table key, the successor host of each range
+// owned by the named host, keyed by the range. It doesn't change the state.
+// The mapping of a key is empty when the host is the last host of its table.
+func (s *DaprHostMemberState) planRemoval(name string) map[string]map[string]string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	plan := map[string]map[string]string{}
+	m, ok := s.Members[name]
+	if !ok || !s.servesHashingTables(m) {
+		return plan
+	}
+
+	for _, key := range m.entityKeys() {
+		t, ok := s.hashingTableMap[key]
+		if !ok {
+			continue
+		}
+		ranges := map[string]string{}
+		for r, host := range t.RangeSuccessors(m.Name) {
+			ranges[r.String()] = host
+		}
+		plan[key] = ranges
+	}
+
+	return plan
+}
+
+// removeMembersWhere removes all members matching pred and increases
+// TableGeneration at most once. pred must not modify the member. It returns
+// the sorted names of the removed members and whether any hashing table is
+// updated.
+func (s *DaprHostMemberState) removeMembersWhere(pred func(*DaprHostMember) bool) (removed []string, changed bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return []string{}, false
+	}
+
+	removed = []string{}
+	for name, m := range s.Members {
+		if !pred(m) {
+			continue
+		}
+		if _, _, updated := s.applyMemberRemove(m); updated {
+			changed = true
+		}
+		removed = append(removed, name)
+	}
+
+	if changed {
+		s.incTableGeneration()
+	}
+
+	sort.Strings(removed)
+	return removed, changed
+}
+
+// removeMembersByAppID removes all members of the AppID, such as when the
+// application is retired, and increases TableGeneration at most once. It
+// returns the sorted names of the removed members and whether any hashing
+// table is updated.
+func (s *DaprHostMemberState) removeMembersByAppID(appID string) (removed []string, changed bool) {
+	return s.removeMembersWhere(func(m *DaprHostMember) bool {
+		return m.AppID == appID
+	})
+}
+
+// ReplaceMembers replaces all members with the given members and rebuilds the
+// hashing tables, as the resync from an authoritative member list. The given
+// members are stored without validation, keeping Index unchanged, but their
+// Actor Types are sorted, deduplicated and interned and their skipped Actor
+// Types are recomputed like upsertMember. It increases TableGeneration once
+// and returns true if any hashing table differs from before.
+func (s *DaprHostMemberState) ReplaceMembers(members []*DaprHostMember) (changed bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return false
+	}
+	// the pending ring operations are discarded when nothing changes below.
+	s.flushPendingGeneration()
+
+	now := s.now()
+	before := s.ringHosts()
+	old := s.Members
+
+	s.rebuildingTables = true
+	for key, hosts := range before {
+		for name := range hosts {
+			// a ring may hold a host missing from Members, see Verify.
+			namespace := keyNamespace(key)
+			if m, ok := old[name]; ok {
+				namespace = m.Namespace
+			}
+			s.recordRingOp(key, namespace, name, false)
+		}
+	}
+	for key, t := range s.hashingTableMap {
+		delete(s.hashingTableMap, key)
+		hashingTablePool.Put(t)
+	}
+	if s.hashingTableMap == nil {
+		s.hashingTableMap = map[string]*hashing.Consistent{}
+	}
+
+	s.Members = make(map[string]*DaprHostMember, len(members))
+	for _, host := range members {
+		if host == nil {
+			continue
+		}
+		m := withSortedEntities(host).clone()
+		s.internEntities(m.Entities)
+		if m.CreatedAt.IsZero() {
+			m.CreatedAt = now
+		}
+		if m.UpdatedAt.IsZero() {
+			m.UpdatedAt = now
+		}
+		s.Members[m.Name] = m
+	}
+
+	for _, name := range sortedMemberNames(old) {
+		if _, ok := s.Members[name]; !ok {
+			s.recordRemoval()
+			s.recordEvent(MembershipEventRemove, old[name])
+			s.notifyMemberRemoved(name)
+		}
+	}
+	for _, name := range sortedMemberNames(s.Members) {
+		m := s.Members[name]
+		m.SkippedEntities = nil
+		if s.servesHashingTables(m) {
+			// the members are added in name order, so that the skipped
+			// Actor Types are deterministic.
+			m.SkippedEntities = s.skippedEntities(m, nil)
+			s.updateHashingTables(m)
+		}
+		if o, ok := old[name]; !ok || !memberEqual(o, m) {
+			s.recordEvent(MembershipEventAdd, m)
+			s.notifyMemberAdded(m)
+		}
+	}
+
+	after := s.ringHosts()
+	s.rebuildingTables = false
+	s.notifyEntityAvailabilityChanges(before, after)
+
+	changed = !cmp.Equal(before, after, cmpopts.EquateEmpty())
+	if changed {
+		s.incTableGeneration()
+	} else {
+		s.pendingRingOps = nil
+		s.ringsBefore = nil
+		// the regions of the hosts may change without any table change.
+		s.refreshAllRegionRings()
+	}
+
+	return changed
+}
+
+// sortedMemberNames returns the sorted names of the members.
+func sortedMemberNames(members map[string]*DaprHostMember) []string {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyMemberRemove removes the member without increasing TableGeneration.
+// It returns whether the member existed, the successor hosts per hashing
+// table key and whether any hashing table is updated. The caller must hold
+// the write lock.
+func (s *DaprHostMemberState) applyMemberRemove(host *DaprHostMember) (existed bool, moved map[string][]string, changed bool) {
+	moved = map[string][]string{}
+	m, ok := s.Members[host.Name]
+	if !ok {
+		return false, moved, false
+	}
+
+	// keep the tombstoned member in the hashing tables until the grace period
+	// elapses. sweepTombstones finalizes the removal.
+	if s.tombstoneGracePeriod > 0 {
+		if m.DeletedAt.IsZero() {
+			m.DeletedAt = s.now()
+		}
+		return true, moved, false
+	}
+
+	if s.servesHashingTables(m) {
+		for _, key := range m.entityKeys() {
+			if t, ok := s.hashingTableMap[key]; ok {
+				moved[key] = t.SuccessorHosts(m.Name)
+			}
+		}
+	}
+
+	return true, moved, s.deleteMember(m)
+}
+
+// deleteMember deletes the member and removes it from the hashing tables
+// without increasing TableGeneration. It returns true if any hashing table
+// is updated. The caller must hold the write lock.
+func (s *DaprHostMemberState) deleteMember(m *DaprHostMember) bool {
+	tableUpdateRequired := false
+	if s.servesHashingTables(m) {
+		s.removeHashingTables(m)
+		tableUpdateRequired = true
+	}
+	delete(s.Members, m.Name)
+	s.recordRemoval()
+	s.recordEvent(MembershipEventRemove, m)
+	s.notifyMemberRemoved(m.Name)
+
+	return tableUpdateRequired
+}
+
+// sweepTombstones finalizes the removal of the tombstoned members whose grace
+// period has elapsed and returns their names in sorted order. TableGeneration
+// is increased once when any hashing table is updated.
+func (s *DaprHostMemberState) sweepTombstones() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return []string{}
+	}
+
+	now := s.now()
+	tableUpdateRequired := false
+	removed := []string{}
+
+	for name, m := range s.Members {
+		if m.DeletedAt.IsZero() || now.Sub(m.DeletedAt) < s.tombstoneGracePeriod {
+			continue
+		}
+		if s.deleteMember(m) {
+			tableUpdateRequired = true
+		}
+		removed = append(removed, name)
+	}
+
+	if tableUpdateRequired {
+		s.incTableGeneration()
+	}
+
+	sort.Strings(removed)
+	return removed
+}
+
+// mergeMemberEntities adds the entities which the known host doesn't report
+// yet to its sorted Entities, for the hosts which report Actor Types incrementally.
+// Unlike upsertMember, the existing entities are kept and only the hashing
+// tables of the added entities are updated, increasing TableGeneration once.
+// It returns true if any hashing table is updated. The member is unchanged
+// if the merged member is invalid.
+func (s *DaprHostMemberState) mergeMemberEntities(name string, entities []string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return false
+	}
+
+	m, ok := s.Members[name]
+	if !ok {
+		return false
+	}
+
+	known := make(map[string]struct{}, len(m.Entities))
+	for _, e := range m.Entities {
+		known[e] = struct{}{}
+	}
+	added := []string{}
+	for _, e := range entities {
+		if _, ok := known[e]; !ok {
+			known[e] = struct{}{}
+			added = append(added, e)
+		}
+	}
+	if len(added) == 0 {
+		return false
+	}
+
+	merged := m.clone()
+	merged.Entities = append(merged.Entities, added...)
+	sort.Strings(merged.Entities)
+	if err := s.validateMember(merged); err != nil {
+		return false
+	}
+
+	s.internEntities(merged.Entities)
+	m.Entities = merged.Entities
+	m.Version++
+	m.UpdatedAt = s.now()
+
+	tableUpdateRequired := false
+	if s.servesHashingTables(m) {
+		skipped := s.skippedEntities(&DaprHostMember{Namespace: m.Namespace, Entities: added}, nil)
+		m.SkippedEntities = append(m.SkippedEntities, skipped...)
+		sort.Strings(m.SkippedEntities)
+		for _, e := range added {
+			if key := EntityKey(m.Namespace, e); !m.skips(key) {
+				s.addToHashingTable(key, m, m.entityWeight(e))
+				tableUpdateRequired = true
+			}
+		}
+	}
+	s.recordEvent(MembershipEventAdd, m)
+	s.notifyMemberAdded(m)
+
+	if tableUpdateRequired {
+		s.incTableGeneration()
+	}
+	return tableUpdateRequired
+}
+
+// setMemberEntities replaces the Entities of the known host and returns the
+// sorted Actor Types which are added and removed. Unlike upsertMember, only
+// the hashing tables of the added and removed Actor Types are updated,
+// increasing TableGeneration once. changed is true if any hashing table is
+// updated. The member is unchanged if it is invalid with the entities.
+func (s *DaprHostMemberState) setMemberEntities(name string, entities []string) (added, removed []string, changed bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return []string{}, []string{}, false
+	}
+
+	added, removed = []string{}, []string{}
+	m, ok := s.Members[name]
+	if !ok {
+		return added, removed, false
+	}
+
+	next := m.clone()
+	next.Entities = append([]string{}, withSortedEntities(&DaprHostMember{Entities: entities}).Entities...)
+	if err := s.validateMember(next); err != nil {
+		return added, removed, false
+	}
+
+	current := make(map[string]struct{}, len(m.Entities))
+	for _, e := range m.Entities {
+		current[e] = struct{}{}
+	}
+	target := make(map[string]struct{}, len(next.Entities))
+	for _, e := range next.Entities {
+		target[e] = struct{}{}
+		if _, ok := current[e]; !ok {
+			added = append(added, e)
+		}
+	}
+	for _, e := range m.Entities {
+		if _, ok := target[e]; !ok {
+			removed = append(removed, e)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return added, removed, false
+	}
+
+	removedKeys := make(map[string]struct{}, len(removed))
+	for _, e := range removed {
+		key := EntityKey(m.Namespace, e)
+		removedKeys[key] = struct{}{}
+		if s.servesHashingTables(m) && !m.skips(key) {
+			s.removeFromHashingTable(key, m)
+			changed = true
+		}
+	}
+	var skipped []string
+	for _, key := range m.SkippedEntities {
+		if _, ok := removedKeys[key]; !ok {
+			skipped = append(skipped, key)
+		}
+	}
+
+	s.internEntities(next.Entities)
+	m.Entities = next.Entities
+	m.SkippedEntities = skipped
+	m.Version++
+	m.UpdatedAt = s.now()
+
+	if s.servesHashingTables(m) {
+		m.SkippedEntities = append(m.SkippedEntities,
+			s.skippedEntities(&DaprHostMember{Namespace: m.Namespace, Entities: added}, nil)...)
+		sort.Strings(m.SkippedEntities)
+		for _, e := range added {
+			if key := EntityKey(m.Namespace, e); !m.skips(key) {
+				s.addToHashingTable(key, m, m.entityWeight(e))
+				changed = true
+			}
+		}
+	}
+	s.recordEvent(MembershipEventAdd, m)
+	s.notifyMemberAdded(m)
+
+	if changed {
+		s.incTableGeneration()
+	}
+	return added, removed, changed
+}
+
+// renameMember renames the member keeping its record such as CreatedAt and
+// Draining. The virtual node positions are derived from the host name, so the
+// renamed host gets new positions and its actors are rebalanced in the same
+// way as removing and adding the host, but with a single TableGeneration
+// increase. It returns false if the old member doesn't exist or the new name
+// is in use.
+func (s *DaprHostMemberState) renameMember(oldName, newName string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return false
+	}
+
+	m, ok := s.Members[oldName]
+	if !ok || oldName == newName {
+		return false
+	}
+	if _, ok := s.Members[newName]; ok {
+		return false
+	}
+
+	tableUpdateRequired := s.servesHashingTables(m) && len(m.entityKeys()) > 0
+	if tableUpdateRequired {
+		s.removeHashingTables(m)
+	}
+
+	delete(s.Members, oldName)
+	s.recordEvent(MembershipEventRemove, m)
+	s.notifyMemberRemoved(oldName)
+
+	m.Name = newName
+	m.Version++
+	m.UpdatedAt = s.now()
+	s.Members[newName] = m
+	if tableUpdateRequired {
+		s.updateHashingTables(m)
+	}
+	s.recordEvent(MembershipEventAdd, m)
+	s.notifyMemberAdded(m)
+
+	if tableUpdateRequired {
+		s.incTableGeneration()
+	}
+
+	return true
+}
+
+// heartbeat updates UpdatedAt of the existing member without any change of
+// the hashing tables, so that liveness pings don't need a full upsert. It
+// returns false, leaving the state unchanged, if the host is unknown or
+// tombstoned, in which case the host must register with upsertMember again.
+func (s *DaprHostMemberState) heartbeat(name string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return false
+	}
+
+	m, ok := s.Members[name]
+	if !ok || !m.DeletedAt.IsZero() {
+		return false
+	}
+	m.UpdatedAt = s.now()
+	return true
+}
+
+// drainMember removes the host from the hashing tables while keeping its
+// member record so that it can be undrained later. It returns true if any
+// hashing table is updated.
+func (s *DaprHostMemberState) drainMember(name string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return false
+	}
+
+	m, ok := s.Members[name]
+	if !ok || m.Draining {
+		return false
+	}
+
+	tableUpdateRequired := s.servesHashingTables(m)
+	if tableUpdateRequired {
+		s.removeHashingTables(m)
+	}
+	m.Draining = true
+	s.recordEvent(MembershipEventDrain, m)
+
+	if tableUpdateRequired {
+		s.incTableGeneration()
+	}
+
+	return tableUpdateRequired
+}
+
+// undrainMember adds the draining host back to the hashing tables.
+// It returns true if any hashing table is updated.
+func (s *DaprHostMemberState) undrainMember(name string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return false
+	}
+
+	m, ok := s.Members[name]
+	if !ok || !m.Draining {
+		return false
+	}
+
+	m.Draining = false
+	s.recordEvent(MembershipEventUndrain, m)
+	m.SkippedEntities = s.skippedEntities(m, nil)
+	tableUpdateRequired := s.servesHashingTables(m) && len(m.entityKeys()) > 0
+	if tableUpdateRequired {
+		s.updateHashingTables(m)
+		s.incTableGeneration()
+	}
+
+	return tableUpdateRequired
+}
+
+// decayMember reduces the weight of the host in all its hashing tables by the
+// fraction 1/steps, where steps is the number of remaining steps including the
+// final removal, so that a heavily weighted host can be removed gradually by
+// calling it with steps, steps-1, ..., 1. The weight never drops below one,
+// and steps of one or less removes the member. Each step which updates any
+// hashing table increases TableGeneration. removed is true if the member is
+// removed.
+func (s *DaprHostMemberState) decayMember(name string, steps int) (removed bool, changed bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return false, false
+	}
+
+	m, ok := s.Members[name]
+	if !ok {
+		return false, false
+	}
+
+	if steps <= 1 {
+		_, _, changed = s.applyMemberRemove(m)
+		if changed {
+			s.incTableGeneration()
+		}
+		return true, changed
+	}
+
+	weight := decayWeight(m.Weight, steps)
+	weights := copyEntityWeights(m.EntityWeights)
+	for e, w := range weights {
+		weights[e] = decayWeight(w, steps)
+	}
+	if weight == m.Weight && cmp.Equal(weights, m.EntityWeights) {
+		return false, false
+	}
+
+	if s.servesHashingTables(m) {
+		s.removeHashingTables(m)
+		changed = true
+	}
+	m.Weight = weight
+	m.EntityWeights = weights
+	m.Version++
+	if changed {
+		s.updateHashingTables(m)
+		s.incTableGeneration()
+	}
+	s.recordEvent(MembershipEventAdd, m)
+	s.notifyMemberAdded(m)
+
+	return false, changed
+}
+
+// decayWeight returns the weight reduced by the fraction 1/steps, but not
+// below one. Weight zero is the same as one.
+func decayWeight(weight, steps int) int {
+	if weight <= 1 {
+		return weight
+	}
+	decayed := weight * (steps - 1) / steps
+	if decayed < 1 {
+		return 1
+	}
+	return decayed
+}
+
+// expireStaleMembers removes the members which have not been updated within ttl
+// and returns the names of the evicted members in sorted order.
+// TableGeneration is increased once when any hashing table is updated.
+func (s *DaprHostMemberState) expireStaleMembers(ttl time.Duration) []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return []string{}
+	}
+
+	now := s.now()
+	tableUpdateRequired := false
+	expired := []string{}
+
+	for name, m := range s.Members {
+		if now.Sub(m.UpdatedAt) <= ttl {
+			continue
+		}
+		if s.deleteMember(m) {
+			tableUpdateRequired = true
+		}
+		expired = append(expired, name)
+	}
+
+	if tableUpdateRequired {
+		s.incTableGeneration()
+	}
+
+	sort.Strings(expired)
+	return expired
+}
+
+// Compact deletes the hashing tables without any host and returns the number
+// of deleted tables. Namespaced tables share the same map, so no other entry
+// is reclaimed. It doesn't increase TableGeneration because the placement
+// tables are unchanged.
+func (s *DaprHostMemberState) Compact() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return 0
+	}
+
+	reclaimed := 0
+	for key, t := range s.hashingTableMap {
+		if t != nil && t.HostCount() > 0 {
+			continue
+		}
+		delete(s.hashingTableMap, key)
+		delete(s.hostSetGenerations, key)
+		if t != nil {
+			hashingTablePool.Put(t)
+		}
+		reclaimed++
+	}
+	if reclaimed > 0 {
+		s.refreshAllRegionRings()
+		s.rebuildEntityFilter()
+	}
+	return reclaimed
+}
+
+// ResolveActorHost returns the name and app ID of the host which owns the
+// actor ID of the given Actor Type. entity is the hashing table key built by
+// EntityKey. ok is false when no hashing table exists for the entity.
+// The owner honors the bounded loads if enabled. See SetBoundedLoads. A pinned
+// actor ID resolves to its pinned host if it is a member. See AddPin.
+func (s *DaprHostMemberState) ResolveActorHost(entity, actorID string) (host string, appID string, ok bool) {
+	if !s.mayHaveEntity(entity) {
+		return "", "", false
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	entity = s.canonicalEntity(entity)
+	return s.resolveActorHost(entity, s.hashingTableMap[entity], actorID)
+}
+
+// canonicalEntity returns the canonical Actor Type of the alias, or the
+// entity itself if it is not an alias. The caller must hold the lock.
+func (s *DaprHostMemberState) canonicalEntity(entity string) string {
+	if canonical, ok := s.Aliases[entity]; ok {
+		return canonical
+	}
+	return entity
+}
+
+// resolveActorHost resolves the actor ID against the pins and the hashing
+// table t of the entity, which is nil if it doesn't exist. The caller must
+// hold the lock.
+func (s *DaprHostMemberState) resolveActorHost(entity string, t *hashing.Consistent, actorID string) (host string, appID string, ok bool) {
+	if name, ok := s.Pins[entity][actorID]; ok {
+		// the pinned host must still serve the entity, otherwise the actor
+		// falls through to the hashing table.
+		m, ok := s.Members[name]
+		if ok && m.DeletedAt.IsZero() && !m.Draining && t != nil && t.Contains(name) {
+			return m.Name, m.AppID, true
+		}
+	}
+
+	if t == nil {
+		return "", "", false
+	}
+
+	if s.loadFactor > 0 {
+		name, err := t.GetLeast(actorID)
+		if err != nil {
+			return "", "", false
+		}
+		_, _, loadMap, _ := t.GetInternals()
+		return name, loadMap[name].AppID, true
+	}
+
+	h, err := t.GetHost(actorID)
+	if err != nil {
+		return "", "", false
+	}
+
+	return h.Name, h.AppID, true
+}
+
+// ResolveBatch returns the host name of each actor ID of the given Actor
+// Type, resolved as ResolveActorHost does but under a single read lock.
+// entity is the hashing table key built by EntityKey. ok is false when no
+// hashing table exists for the entity.
+func (s *DaprHostMemberState) ResolveBatch(entity string, actorIDs []string) (map[string]string, bool) {
+	if !s.mayHaveEntity(entity) {
+		return nil, false
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	entity = s.canonicalEntity(entity)
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return nil, false
+	}
+
+	hosts := make(map[string]string, len(actorIDs))
+	for _, id := range actorIDs {
+		if host, _, ok := s.resolveActorHost(entity, t, id); ok {
+			hosts[id] = host
+		}
+	}
+	return hosts, true
+}
+
+// AddPin pins the actor ID of the given Actor Type to the host, so that
+// ResolveActorHost returns the host regardless of the hashing table while the
+// host is a member. entity is the hashing table key built by EntityKey. The
+// hashing tables and TableGeneration are unchanged. It returns ErrFrozen or
+// ErrRestoring if the state can't be changed.
+func (s *DaprHostMemberState) AddPin(entity, actorID, host string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.checkMutable(); err != nil {
+		return err
+	}
+
+	if s.Pins == nil {
+		s.Pins = map[string]map[string]string{}
+	}
+	if _, ok := s.Pins[entity]; !ok {
+		s.Pins[entity] = map[string]string{}
+	}
+	s.Pins[entity][actorID] = host
+	s.addToEntityFilter(entity)
+	return nil
+}
+
+// RemovePin removes the pin of the actor ID of the given Actor Type. It
+// returns false if the actor ID is not pinned or the state can't be changed.
+func (s *DaprHostMemberState) RemovePin(entity, actorID string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return false
+	}
+	if _, ok := s.Pins[entity][actorID]; !ok {
+		return false
+	}
+	delete(s.Pins[entity], actorID)
+	if len(s.Pins[entity]) == 0 {
+		delete(s.Pins, entity)
+		s.rebuildEntityFilter()
+	}
+	return true
+}
+
+// AddAlias makes ResolveActorHost and ResolveBatch resolve the actors of the
+// alias through the hashing table of the canonical entity, for example while
+// an Actor Type is renamed. No hashing table is created for the alias, and
+// the hashing tables and TableGeneration are unchanged. Both are hashing
+// table keys built by EntityKey. It returns ErrFrozen or ErrRestoring if the
+// state can't be changed.
+func (s *DaprHostMemberState) AddAlias(alias, entity string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.checkMutable(); err != nil {
+		return err
+	}
+
+	if s.Aliases == nil {
+		s.Aliases = map[string]string{}
+	}
+	s.Aliases[alias] = entity
+	s.addToEntityFilter(alias)
+	return nil
+}
+
+// RemoveAlias removes the alias. It returns false if the alias doesn't exist
+// or the state can't be changed.
+func (s *DaprHostMemberState) RemoveAlias(alias string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.checkMutable() != nil {
+		return false
+	}
+	if _, ok := s.Aliases[alias]; !ok {
+		return false
+	}
+	delete(s.Aliases, alias)
+	s.rebuildEntityFilter()
+	return true
+}
+
+// ResolveActorReplicas returns up to n distinct hosts clockwise from the actor
+// ID of the given Actor Type, starting with the owner of the actor ID. entity
+// is the hashing table key built by EntityKey. It returns an empty slice when
+// no hashing table exists for the entity.
+func (s *DaprHostMemberState) ResolveActorReplicas(entity, actorID string, n int) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return []string{}
+	}
+	return t.GetN(actorID, n)
+}
+
+// ResolveActorCandidates returns all distinct hosts of the hashing table in
+// the clockwise order from the actor ID of the given Actor Type, as the
+// failover order. The order is the same for the same hashing table. entity
+// is the hashing table key built by EntityKey. It returns an empty slice when
+// no hashing table exists for the entity.
+func (s *DaprHostMemberState) ResolveActorCandidates(entity, actorID string) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return []string{}
+	}
+	return t.GetN(actorID, t.HostCount())
+}
+
+func (s *DaprHostMemberState) isActorHost(host *DaprHostMember) bool {
+	return len(host.Entities) > 0
+}
+
+// servesHashingTables returns true if the host is placed in the hashing tables.
+func (s *DaprHostMemberState) servesHashingTables(host *DaprHostMember) bool {
+	return s.isActorHost(host) && !host.Draining
+}
+
+// restoreCheckInterval is the number of members restored between the checks
+// for cancellation in restoreHashingTablesCtx.
+const restoreCheckInterval = 128
+
+// restoreHashingTables rebuilds all hashing tables from Members. This is used
+// on cold start such as snapshot restore. Use restoreHashingTablesFor to
+// rebuild only the tables affected by a partial recovery.
+func (s *DaprHostMemberState) restoreHashingTables() {
+	// background context is never cancelled.
+	_ = s.restoreHashingTablesCtx(context.Background())
+}
+
+// restoreHashingTablesCtx rebuilds all hashing tables from Members and
+// returns the error of ctx if it is cancelled in the middle. The tables are
+// built without holding the lock, so that reads are served from the previous
+// tables meanwhile, while the mutations of the state return ErrRestoring
+// until a rebuild completes. A cancelled rebuild keeps the previous tables,
+// which are empty for a decoded state, and the state keeps rejecting the
+// mutations until it is restored again.
+func (s *DaprHostMemberState) restoreHashingTablesCtx(ctx context.Context) error {
+	return s.rebuildHashingTables(ctx, nil)
+}
+
+// tableAdd is a host to be added to a hashing table by rebuildHashingTables.
+type tableAdd struct {
+	key    string
+	host   *DaprHostMember
+	weight int
+}
+
+// rebuildHashingTables rebuilds all hashing tables from Members as described
+// by restoreHashingTablesCtx. seed, if not nil, is called with the write lock
+// held after the rebuilt tables are installed and before the mutations are
+// allowed again.
+func (s *DaprHostMemberState) rebuildHashingTables(ctx context.Context, seed func()) error {
+	s.lock.Lock()
+	s.restoring = true
+
+	// rebuilding discards the pending ring operations.
+	s.flushPendingGeneration()
+
+	if s.hashingTableMap == nil {
+		s.hashingTableMap = map[string]*hashing.Consistent{}
+	}
+
+	// members are added in the sorted order, so that the rebuilt tables don't
+	// depend on the iteration order of Members. Members doesn't change until
+	// the rebuild completes because the mutations are rejected.
+	tables := map[string]*hashing.Consistent{}
+	adds := []tableAdd{}
+	for _, name := range sortedMemberNames(s.Members) {
+		m := s.Members[name]
+		s.internEntities(m.Entities)
+		if !s.servesHashingTables(m) {
+			continue
+		}
+		for _, e := range m.Entities {
+			key := EntityKey(m.Namespace, e)
+			if m.skips(key) {
+				continue
+			}
+			if _, ok := tables[key]; !ok {
+				tables[key] = s.newHashingTable()
+			}
+			adds = append(adds, tableAdd{key: key, host: m, weight: m.entityWeight(e)})
+		}
+	}
+	s.lock.Unlock()
+
+	for i, a := range adds {
+		if i%restoreCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				for _, t := range tables {
+					hashingTablePool.Put(t)
+				}
+				return err
+			}
+		}
+		tables[a.key].AddWithWeight(a.host.Name, a.host.AppID, 0, a.weight)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.rebuildingTables = true
+	defer func() { s.rebuildingTables = false }()
+
+	s.hashingTableMap = tables
+	for _, a := range adds {
+		s.markPlaced(a.host)
+	}
+	// rebuilding the same tables is not a change of the table generation.
+	s.pendingRingOps = nil
+	s.ringsBefore = nil
+	s.hostSetGenerations = nil
+	keys := make(map[string]struct{}, len(s.hashingTableMap))
+	for key := range s.hashingTableMap {
+		keys[key] = struct{}{}
+	}
+	s.resetHostSetGenerations(keys)
+	s.refreshAllRegionRings()
+	s.rebuildEntityFilter()
+	if seed != nil {
+		seed()
+	}
+	s.restoring = false
+
+	return nil
+}
+
+// restoreHashingTablesFor rebuilds only the hashing tables of the given keys
+// from Members, leaving the other tables untouched. This is much cheaper than
+// restoreHashingTables when a few tables need to be corrected.
+func (s *DaprHostMemberState) restoreHashingTablesFor(entities map[string]struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// rebuilding discards the pending ring operations.
+	s.flushPendingGeneration()
+
+	if s.hashingTableMap == nil {
+		s.hashingTableMap = map[string]*hashing.Consistent{}
+	}
+
+	s.rebuildingTables = true
+	defer func() { s.rebuildingTables = false }()
+
+	for key := range entities {
+		delete(s.hashingTableMap, key)
+	}
+
+	for _, name := range sortedMemberNames(s.Members) {
+		m := s.Members[name]
+		if !s.servesHashingTables(m) {
+			continue
+		}
+		for _, e := range m.Entities {
+			key := EntityKey(m.Namespace, e)
+			if _, ok := entities[key]; ok && !m.skips(key) {
+				s.addToHashingTable(key, m, m.entityWeight(e))
+			}
+		}
 	}
+	s.pendingRingOps = nil
+	s.ringsBefore = nil
+	s.resetHostSetGenerations(entities)
+	s.refreshRegionRings(entities)
+	s.rebuildEntityFilter()
 }
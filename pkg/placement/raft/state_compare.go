@@ -0,0 +1,262 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"sort"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// ringHost is the comparable information of a host in a hashing table.
+type ringHost struct {
+	AppID  string
+	Weight int
+}
+
+// stateView is a point-in-time copy of the comparable parts of the state.
+type stateView struct {
+	index           uint64
+	tableGeneration uint64
+	members         map[string]*DaprHostMember
+	rings           map[string]map[string]ringHost
+}
+
+func (s *DaprHostMemberState) view() *stateView {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	v := &stateView{
+		index:           s.Index,
+		tableGeneration: s.TableGeneration,
+		members:         make(map[string]*DaprHostMember, len(s.Members)),
+	}
+	for k, m := range s.Members {
+		v.members[k] = m.clone()
+	}
+	v.rings = s.ringHosts()
+	return v
+}
+
+// ringHosts returns the hosts of every hashing table. The caller must hold
+// the lock.
+func (s *DaprHostMemberState) ringHosts() map[string]map[string]ringHost {
+	rings := make(map[string]map[string]ringHost, len(s.hashingTableMap))
+	for k, t := range s.hashingTableMap {
+		rings[k] = tableRingHosts(t)
+	}
+	return rings
+}
+
+// tableRingHosts returns the hosts of the hashing table.
+func tableRingHosts(t *hashing.Consistent) map[string]ringHost {
+	_, _, loadMap, _ := t.GetInternals()
+	hosts := make(map[string]ringHost, len(loadMap))
+	for name, h := range loadMap {
+		hosts[name] = ringHost{AppID: h.AppID, Weight: h.Weight}
+	}
+	return hosts
+}
+
+// EntityChecksum returns the checksum of the hosts, their App IDs and weights
+// in the hashing table of the key. Unlike TableGeneration, it depends only on
+// the hosts, so it is identical across restarts of the placement service
+// given the same members. ok is false when the table doesn't exist.
+func (s *DaprHostMemberState) EntityChecksum(entity string) (uint64, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return 0, false
+	}
+	return ringChecksum(tableRingHosts(t)), true
+}
+
+// StateChecksum returns the checksum of all hashing tables, combining the
+// keys with their EntityChecksum in the sorted order of the keys.
+func (s *DaprHostMemberState) StateChecksum() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	rings := s.ringHosts()
+	keys := make([]string, 0, len(rings))
+	for k := range rings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, k := range keys {
+		writeChecksumString(h, k)
+		binary.BigEndian.PutUint64(buf[:], ringChecksum(rings[k]))
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// RingFingerprint returns the FNV-1a hash of the virtual nodes of the hashing
+// table of the key, as the (hash, host) pairs in the order of the ring. Unlike
+// EntityChecksum, it covers the ring itself, so that comparing fingerprints
+// taken while the members are unchanged detects a corrupted ring. ok is false
+// when the table doesn't exist.
+func (s *DaprHostMemberState) RingFingerprint(entity string) (uint64, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return 0, false
+	}
+
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, p := range t.Ring() {
+		binary.BigEndian.PutUint64(buf[:], p.Hash)
+		h.Write(buf[:])
+		writeChecksumString(h, p.Host)
+	}
+	return h.Sum64(), true
+}
+
+// ringChecksum returns the FNV-1a hash of the hosts in the sorted order of
+// their names.
+func ringChecksum(hosts map[string]ringHost) uint64 {
+	names := make([]string, 0, len(hosts))
+	for name := range hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, name := range names {
+		writeChecksumString(h, name)
+		writeChecksumString(h, hosts[name].AppID)
+		binary.BigEndian.PutUint64(buf[:], uint64(int64(hosts[name].Weight)))
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+// writeChecksumString writes the length prefixed string, so that adjacent
+// strings can't be confused with each other.
+func writeChecksumString(w io.Writer, str string) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(len(str)))
+	w.Write(buf[:])
+	io.WriteString(w, str)
+}
+
+// MemberChange is a member whose fields differ between two states.
+type MemberChange struct {
+	Name string
+	Old  *DaprHostMember
+	New  *DaprHostMember
+}
+
+// StateDiff is the difference between two states. All lists are sorted.
+type StateDiff struct {
+	// Added is the names of the members only in the new state.
+	Added []string
+	// Removed is the names of the members only in the old state.
+	Removed []string
+	// Changed is the members in both states with different fields.
+	Changed []MemberChange
+	// Entities is the hashing table keys whose hosts differ.
+	Entities []string
+}
+
+// Diff returns the difference between the members and hashing tables of
+// the old and new states. A nil state is treated as an empty state.
+func Diff(oldState, newState *DaprHostMemberState) StateDiff {
+	a, b := oldState.viewOrEmpty(), newState.viewOrEmpty()
+	d := StateDiff{
+		Added:    []string{},
+		Removed:  []string{},
+		Changed:  []MemberChange{},
+		Entities: []string{},
+	}
+
+	for k, m := range a.members {
+		o, ok := b.members[k]
+		if !ok {
+			d.Removed = append(d.Removed, k)
+			continue
+		}
+		if !memberEqual(m, o) {
+			d.Changed = append(d.Changed, MemberChange{Name: k, Old: m, New: o})
+		}
+	}
+	for k := range b.members {
+		if _, ok := a.members[k]; !ok {
+			d.Added = append(d.Added, k)
+		}
+	}
+
+	entities := map[string]struct{}{}
+	for k, hosts := range a.rings {
+		if !cmp.Equal(hosts, b.rings[k], cmpopts.EquateEmpty()) {
+			entities[k] = struct{}{}
+		}
+	}
+	for k, hosts := range b.rings {
+		if !cmp.Equal(hosts, a.rings[k], cmpopts.EquateEmpty()) {
+			entities[k] = struct{}{}
+		}
+	}
+	d.Entities = sortedKeys(entities)
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool {
+		return d.Changed[i].Name < d.Changed[j].Name
+	})
+	return d
+}
+
+func (s *DaprHostMemberState) viewOrEmpty() *stateView {
+	if s == nil {
+		return &stateView{}
+	}
+	return s.view()
+}
+
+// memberEqual returns true if both members have identical fields.
+func memberEqual(a, b *DaprHostMember) bool {
+	return cmp.Equal(a, b, cmpopts.EquateEmpty())
+}
+
+// Equal returns true if both states have the same Index, TableGeneration,
+// Members and hosts in every hashing table.
+func (s *DaprHostMemberState) Equal(other *DaprHostMemberState) bool {
+	if s == other {
+		return true
+	}
+	if s == nil || other == nil {
+		return false
+	}
+
+	a, b := s.view(), other.view()
+	if a.index != b.index || a.tableGeneration != b.tableGeneration {
+		return false
+	}
+	if len(a.members) != len(b.members) {
+		return false
+	}
+	for k, m := range a.members {
+		if o, ok := b.members[k]; !ok || !memberEqual(m, o) {
+			return false
+		}
+	}
+	return cmp.Equal(a.rings, b.rings, cmpopts.EquateEmpty())
+}
@@ -0,0 +1,308 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	newTestState := func() *DaprHostMemberState {
+		s := newDaprHostMemberState()
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+			Labels:   map[string]string{"region": "west"},
+		})
+		s.upsertMember(&DaprHostMember{
+			Name:  "127.0.0.1:8081",
+			AppID: "FakeID_2",
+		})
+		return s
+	}
+
+	t.Run("clone with restored tables", func(t *testing.T) {
+		s := newTestState()
+		c := s.clone()
+		c.restoreHashingTables()
+
+		assert.True(t, s.Equal(c))
+		assert.True(t, c.Equal(s))
+		assert.True(t, s.Equal(s))
+	})
+
+	t.Run("clone without tables", func(t *testing.T) {
+		s := newTestState()
+		assert.False(t, s.Equal(s.clone()))
+	})
+
+	t.Run("different member field", func(t *testing.T) {
+		s := newTestState()
+		c := s.clone()
+		c.restoreHashingTables()
+		c.Members["127.0.0.1:8080"].Labels["region"] = "east"
+
+		assert.False(t, s.Equal(c))
+	})
+
+	t.Run("different table generation", func(t *testing.T) {
+		s := newTestState()
+		c := s.clone()
+		c.restoreHashingTables()
+		c.TableGeneration++
+
+		assert.False(t, s.Equal(c))
+	})
+
+	t.Run("different hashing table hosts", func(t *testing.T) {
+		s := newTestState()
+		c := s.clone()
+		c.restoreHashingTables()
+		c.hashingTableMap["actorTypeOne"].Add("127.0.0.1:8081", "FakeID_2", 0)
+
+		assert.False(t, s.Equal(c))
+	})
+
+	t.Run("nil state", func(t *testing.T) {
+		assert.False(t, newTestState().Equal(nil))
+	})
+}
+
+func TestDiff(t *testing.T) {
+	// arrange
+	old := newDaprHostMemberState()
+	old.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	old.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeTwo"},
+	})
+
+	t.Run("identical states", func(t *testing.T) {
+		c := old.clone()
+		c.restoreHashingTables()
+
+		d := Diff(old, c)
+		assert.Empty(t, d.Added)
+		assert.Empty(t, d.Removed)
+		assert.Empty(t, d.Changed)
+		assert.Empty(t, d.Entities)
+	})
+
+	t.Run("added, removed and changed members", func(t *testing.T) {
+		// act
+		current := old.clone()
+		current.restoreHashingTables()
+		current.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+		current.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeThree"},
+		})
+		current.upsertMember(&DaprHostMember{
+			Name:  "127.0.0.1:8082",
+			AppID: "FakeID_3",
+		})
+		d := Diff(old, current)
+
+		// assert
+		assert.Equal(t, []string{"127.0.0.1:8082"}, d.Added)
+		assert.Equal(t, []string{"127.0.0.1:8081"}, d.Removed)
+		assert.Equal(t, 1, len(d.Changed))
+		assert.Equal(t, "127.0.0.1:8080", d.Changed[0].Name)
+		assert.Equal(t, []string{"actorTypeOne"}, d.Changed[0].Old.Entities)
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeThree"}, d.Changed[0].New.Entities)
+		assert.Equal(t, []string{"actorTypeThree", "actorTypeTwo"}, d.Entities)
+	})
+
+	t.Run("nil state", func(t *testing.T) {
+		d := Diff(nil, old)
+		assert.Equal(t, []string{"127.0.0.1:8080", "127.0.0.1:8081"}, d.Added)
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, d.Entities)
+	})
+}
+
+func TestEntityChecksum(t *testing.T) {
+	newTestState := func(names ...string) *DaprHostMemberState {
+		s := newDaprHostMemberState()
+		for _, name := range names {
+			s.upsertMember(&DaprHostMember{
+				Name:     name,
+				AppID:    "FakeID",
+				Entities: []string{"actorTypeOne", "actorTypeTwo"},
+			})
+		}
+		return s
+	}
+
+	t.Run("identical members in any order", func(t *testing.T) {
+		// arrange
+		a := newTestState("127.0.0.1:8080", "127.0.0.1:8081")
+		b := newTestState("127.0.0.1:8081", "127.0.0.1:8080")
+		b.TableGeneration += 10
+
+		// act
+		checksumA, okA := a.EntityChecksum("actorTypeOne")
+		checksumB, okB := b.EntityChecksum("actorTypeOne")
+
+		// assert
+		assert.True(t, okA)
+		assert.True(t, okB)
+		assert.Equal(t, checksumA, checksumB)
+		assert.Equal(t, a.StateChecksum(), b.StateChecksum())
+	})
+
+	t.Run("restored tables", func(t *testing.T) {
+		// arrange
+		s := newTestState("127.0.0.1:8080", "127.0.0.1:8081")
+
+		// act
+		restored := s.cloneWithTables()
+
+		// assert
+		assert.Equal(t, s.StateChecksum(), restored.StateChecksum())
+	})
+
+	t.Run("weight change", func(t *testing.T) {
+		// arrange
+		s := newTestState("127.0.0.1:8080", "127.0.0.1:8081")
+		before, _ := s.EntityChecksum("actorTypeOne")
+		beforeState := s.StateChecksum()
+
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+			Weight:   3,
+		})
+
+		// assert
+		after, _ := s.EntityChecksum("actorTypeOne")
+		assert.NotEqual(t, before, after)
+		assert.NotEqual(t, beforeState, s.StateChecksum())
+	})
+
+	t.Run("host set change", func(t *testing.T) {
+		// arrange
+		s := newTestState("127.0.0.1:8080", "127.0.0.1:8081")
+		before, _ := s.EntityChecksum("actorTypeOne")
+
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+
+		// assert
+		after, _ := s.EntityChecksum("actorTypeOne")
+		assert.NotEqual(t, before, after)
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		// arrange
+		s := newTestState("127.0.0.1:8080")
+
+		// act
+		_, ok := s.EntityChecksum("actorTypeThree")
+
+		// assert
+		assert.False(t, ok)
+	})
+}
+
+func TestRingFingerprint(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	for i := 0; i < 3; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+			Labels:   map[string]string{"region": "west"},
+		})
+	}
+	fingerprint, ok := s.RingFingerprint("actorTypeOne")
+	assert.True(t, ok)
+
+	invariants := []struct {
+		name string
+		op   func()
+	}{
+		{"heartbeat", func() {
+			s.upsertMember(&DaprHostMember{
+				Name:     "127.0.0.1:8080",
+				AppID:    "FakeID",
+				Entities: []string{"actorTypeOne"},
+				Labels:   map[string]string{"region": "west"},
+			})
+		}},
+		{"label change", func() {
+			s.upsertMember(&DaprHostMember{
+				Name:     "127.0.0.1:8080",
+				AppID:    "FakeID",
+				Entities: []string{"actorTypeOne"},
+				Labels:   map[string]string{"region": "east"},
+			})
+		}},
+		{"app id change", func() {
+			s.upsertMember(&DaprHostMember{
+				Name:     "127.0.0.1:8081",
+				AppID:    "FakeID_2",
+				Entities: []string{"actorTypeOne"},
+				Labels:   map[string]string{"region": "west"},
+			})
+		}},
+		{"other entity change", func() {
+			s.upsertMember(&DaprHostMember{
+				Name:     "127.0.0.1:9090",
+				AppID:    "FakeID",
+				Entities: []string{"actorTypeTwo"},
+			})
+		}},
+		{"restore", func() {
+			s.restoreHashingTables()
+		}},
+	}
+
+	for _, tc := range invariants {
+		t.Run(tc.name, func(t *testing.T) {
+			// act
+			tc.op()
+
+			// assert
+			got, ok := s.RingFingerprint("actorTypeOne")
+			assert.True(t, ok)
+			assert.Equal(t, fingerprint, got)
+		})
+	}
+
+	t.Run("host change", func(t *testing.T) {
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8082"})
+
+		// assert
+		got, _ := s.RingFingerprint("actorTypeOne")
+		assert.NotEqual(t, fingerprint, got)
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		// act
+		_, ok := s.RingFingerprint("actorTypeThree")
+
+		// assert
+		assert.False(t, ok)
+	})
+}
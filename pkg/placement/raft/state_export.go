@@ -0,0 +1,135 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// exportedState is the JSON representation of DaprHostMemberState.
+// Members are sorted by name to make the output deterministic.
+type exportedState struct {
+	SchemaVersion   int               `json:"schemaVersion"`
+	Index           uint64            `json:"index"`
+	TableGeneration uint64            `json:"tableGeneration"`
+	Members         []*DaprHostMember `json:"members"`
+
+	Pins    map[string]map[string]string `json:"pins,omitempty"`
+	Aliases map[string]string            `json:"aliases,omitempty"`
+
+	EntityChangedAt      map[string]time.Time `json:"entityChangedAt,omitempty"`
+	NamespaceGenerations map[string]uint64    `json:"namespaceGenerations,omitempty"`
+}
+
+// MarshalState serializes Index, TableGeneration, Members, Pins, Aliases,
+// EntityChangedAt and NamespaceGenerations to JSON.
+// The output is deterministic so that two dumps of the same state are identical.
+func (s *DaprHostMemberState) MarshalState() ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	out := exportedState{
+		SchemaVersion:   s.SchemaVersion,
+		Index:           s.Index,
+		TableGeneration: s.TableGeneration,
+		Members:         make([]*DaprHostMember, 0, len(s.Members)),
+		Pins:            s.Pins,
+		Aliases:         s.Aliases,
+		EntityChangedAt: s.EntityChangedAt,
+
+		NamespaceGenerations: s.NamespaceGenerations,
+	}
+
+	for _, m := range s.Members {
+		out.Members = append(out.Members, m)
+	}
+	sort.Slice(out.Members, func(i, j int) bool {
+		return out.Members[i].Name < out.Members[j].Name
+	})
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// LoadState deserializes the JSON produced by MarshalState and rebuilds
+// the consistent hashing tables. The state of older schema versions is migrated
+// to SchemaVersion.
+func LoadState(data []byte) (*DaprHostMemberState, error) {
+	var in exportedState
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+
+	s := newDaprHostMemberState()
+	s.SchemaVersion = in.SchemaVersion
+	s.Index = in.Index
+	s.TableGeneration = in.TableGeneration
+	s.Pins = in.Pins
+	s.Aliases = in.Aliases
+	s.EntityChangedAt = in.EntityChangedAt
+	s.NamespaceGenerations = in.NamespaceGenerations
+	for _, m := range in.Members {
+		if m == nil {
+			continue
+		}
+		if _, ok := s.Members[m.Name]; ok {
+			return nil, errors.Errorf("duplicated member name: %s", m.Name)
+		}
+		s.Members[m.Name] = m
+	}
+	if err := migrateState(s); err != nil {
+		return nil, err
+	}
+	s.restoreHashingTables()
+
+	return s, nil
+}
+
+// ownershipCSVHeader is the header row of WriteOwnershipCSV.
+var ownershipCSVHeader = []string{"entity", "host", "appID"}
+
+// WriteOwnershipCSV writes the hosts of every hashing table as CSV rows of
+// entity, host and app ID after the header row. entity is the hashing table
+// key built by EntityKey. The rows are sorted by entity and then host, and
+// are written while the read lock is held, so w should not block.
+func (s *DaprHostMemberState) WriteOwnershipCSV(w io.Writer) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ownershipCSVHeader); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(s.hashingTableMap))
+	for key := range s.hashingTableMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		_, _, loadMap, _ := s.hashingTableMap[key].GetInternals()
+		hosts := make([]string, 0, len(loadMap))
+		for name := range loadMap {
+			hosts = append(hosts, name)
+		}
+		sort.Strings(hosts)
+
+		for _, name := range hosts {
+			if err := cw.Write([]string{key, name, loadMap[name].AppID}); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,177 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalAndLoadState(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.Index = 10
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+
+	t.Run("deterministic output", func(t *testing.T) {
+		// act
+		first, err := s.MarshalState()
+		assert.NoError(t, err)
+		second, err := s.MarshalState()
+		assert.NoError(t, err)
+
+		// assert
+		assert.Equal(t, string(first), string(second))
+		assert.Less(t,
+			strings.Index(string(first), "127.0.0.1:8080"),
+			strings.Index(string(first), "127.0.0.1:8081"))
+	})
+
+	t.Run("round trip rebuilds hashing tables", func(t *testing.T) {
+		// act
+		data, err := s.MarshalState()
+		assert.NoError(t, err)
+		loaded, err := LoadState(data)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, s.Index, loaded.Index)
+		assert.Equal(t, s.TableGeneration, loaded.TableGeneration)
+		assert.Equal(t, 2, len(loaded.Members))
+		assert.Equal(t, 2, len(loaded.hashingTableMap))
+		assert.ElementsMatch(t,
+			s.hashingTableMap["actorTypeOne"].Hosts(),
+			loaded.hashingTableMap["actorTypeOne"].Hosts())
+	})
+
+	t.Run("round trip keeps pins and aliases", func(t *testing.T) {
+		// arrange
+		hashing.SetReplicationFactor(10)
+		defer hashing.SetReplicationFactor(0)
+		c := s.cloneWithTables()
+		assert.NoError(t, c.AddPin("actorTypeOne", "actor1", "127.0.0.1:8081"))
+		assert.NoError(t, c.AddAlias("actorTypeOld", "actorTypeOne"))
+
+		// act
+		data, err := c.MarshalState()
+		assert.NoError(t, err)
+		loaded, err := LoadState(data)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, c.Pins, loaded.Pins)
+		assert.Equal(t, c.Aliases, loaded.Aliases)
+		host, _, ok := loaded.ResolveActorHost("actorTypeOld", "actor1")
+		assert.True(t, ok)
+		assert.Equal(t, "127.0.0.1:8081", host)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		// act
+		_, err := LoadState([]byte("{"))
+
+		// assert
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadStateSchemaVersion(t *testing.T) {
+	t.Run("current version", func(t *testing.T) {
+		// act
+		data, err := newDaprHostMemberState().MarshalState()
+		assert.NoError(t, err)
+		loaded, err := LoadState(data)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `"schemaVersion": 2`)
+		assert.Equal(t, SchemaVersion, loaded.SchemaVersion)
+	})
+
+	t.Run("migrate unversioned state", func(t *testing.T) {
+		// act
+		loaded, err := LoadState([]byte(`{"index":3,"members":[{"Name":"127.0.0.1:8080","AppID":"FakeID","Entities":["actorTypeOne"]}]}`))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, SchemaVersion, loaded.SchemaVersion)
+		assert.Equal(t, uint64(3), loaded.Index)
+		assert.Equal(t, "", loaded.Members["127.0.0.1:8080"].Namespace)
+		assert.Equal(t, 1, len(loaded.hashingTableMap))
+	})
+
+	t.Run("migrate version 1 state", func(t *testing.T) {
+		// act
+		loaded, err := LoadState([]byte(`{"schemaVersion":1,"index":3,"tableGeneration":4,"members":[` +
+			`{"Name":"127.0.0.1:8080","AppID":"FakeID","Namespace":"ns1","Entities":["actorTypeOne"]},` +
+			`{"Name":"127.0.0.1:8081","AppID":"FakeID","Entities":["actorTypeOne"]}]}`))
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, SchemaVersion, loaded.SchemaVersion)
+		assert.Equal(t, uint64(4), loaded.NamespaceGeneration("ns1"))
+		assert.Equal(t, uint64(4), loaded.NamespaceGeneration(""))
+		assert.Equal(t, uint64(4), loaded.EntityHostSetGeneration("ns1/actorTypeOne"))
+		_, ok := loaded.EntityLastChanged("ns1/actorTypeOne")
+		assert.False(t, ok)
+		assert.Nil(t, loaded.Pins)
+		assert.Nil(t, loaded.Aliases)
+	})
+
+	t.Run("unknown future version", func(t *testing.T) {
+		// act
+		_, err := LoadState([]byte(`{"schemaVersion":100}`))
+
+		// assert
+		assert.Error(t, err)
+	})
+}
+
+func TestWriteOwnershipCSV(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeTwo", "actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:      "127.0.0.1:8080",
+		AppID:     "FakeID",
+		Namespace: "ns1",
+		Entities:  []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8082",
+		AppID:    "FakeID,3",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	// act
+	var buf bytes.Buffer
+	err := s.WriteOwnershipCSV(&buf)
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, "entity,host,appID\n"+
+		"actorTypeOne,127.0.0.1:8081,FakeID_2\n"+
+		"actorTypeOne,127.0.0.1:8082,\"FakeID,3\"\n"+
+		"actorTypeTwo,127.0.0.1:8081,FakeID_2\n"+
+		EntityKey("ns1", "actorTypeOne")+",127.0.0.1:8080,FakeID\n", buf.String())
+}
@@ -0,0 +1,107 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+)
+
+var propertySeed = flag.Int64("placement.seed", 0, "seed of the placement state property tests, 0 for the default seeds")
+
+// stateFuzzer applies random sequences of upsertMember and removeMember to a
+// state and checks its invariants after every operation. The sequence is
+// fully determined by the seed.
+type stateFuzzer struct {
+	rnd     *rand.Rand
+	state   *DaprHostMemberState
+	checker *invariantChecker
+
+	hosts      []string
+	appIDs     []string
+	namespaces []string
+	entities   []string
+}
+
+func newStateFuzzer(seed int64) *stateFuzzer {
+	s := newDaprHostMemberState()
+	return &stateFuzzer{
+		rnd:        rand.New(rand.NewSource(seed)), // nolint:gosec
+		state:      s,
+		checker:    newInvariantChecker(s),
+		hosts:      []string{"127.0.0.1:8080", "127.0.0.1:8081", "127.0.0.1:8082", "127.0.0.1:8083", "127.0.0.1:8084"},
+		appIDs:     []string{"FakeID", "FakeID_2"},
+		namespaces: []string{"", "ns1"},
+		entities:   []string{"actorTypeOne", "actorTypeTwo", "actorTypeThree"},
+	}
+}
+
+// randomMember returns a member with one of the known host names and a random
+// subset of the known entities.
+func (f *stateFuzzer) randomMember() *DaprHostMember {
+	m := &DaprHostMember{
+		Name:      f.hosts[f.rnd.Intn(len(f.hosts))],
+		AppID:     f.appIDs[f.rnd.Intn(len(f.appIDs))],
+		Namespace: f.namespaces[f.rnd.Intn(len(f.namespaces))],
+		Entities:  []string{},
+		Weight:    f.rnd.Intn(3),
+	}
+	for _, e := range f.entities {
+		if f.rnd.Intn(2) == 0 {
+			m.Entities = append(m.Entities, e)
+		}
+	}
+	return m
+}
+
+// step applies one random operation and returns its description and the
+// violated invariants.
+func (f *stateFuzzer) step() (string, []error) {
+	m := f.randomMember()
+	if f.rnd.Intn(3) == 0 {
+		changed, _ := f.state.removeMember(m)
+		return fmt.Sprintf("remove %s", m.Name), f.checker.check(f.state, changed)
+	}
+
+	changed, err := f.state.upsertMember(m)
+	op := fmt.Sprintf("upsert %s %s/%v weight %d", m.Name, m.Namespace, m.Entities, m.Weight)
+	if err != nil {
+		return op, []error{err}
+	}
+	return op, f.checker.check(f.state, changed)
+}
+
+// run applies steps random operations and fails the test at the first
+// operation which violates an invariant.
+func (f *stateFuzzer) run(t *testing.T, seed int64, steps int) {
+	for i := 0; i < steps; i++ {
+		op, errs := f.step()
+		if len(errs) > 0 {
+			t.Fatalf("seed %d, step %d (%s): %v", seed, i, op, errs)
+		}
+	}
+}
+
+func TestStateInvariants(t *testing.T) {
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	seeds := []int64{1, 2, 3, 42}
+	if *propertySeed != 0 {
+		seeds = []int64{*propertySeed}
+	}
+
+	for _, seed := range seeds {
+		seed := seed
+		t.Run(fmt.Sprintf("seed %d", seed), func(t *testing.T) {
+			newStateFuzzer(seed).run(t, seed, 500)
+		})
+	}
+}
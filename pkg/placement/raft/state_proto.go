@@ -0,0 +1,218 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	v1pb "github.com/dapr/dapr/pkg/proto/placement/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// MarshalProto serializes the persisted fields of the state to the
+// PlacementState protobuf message of
+// dapr/proto/placement/v1/placement_state.proto. The output is
+// deterministic.
+func (s *DaprHostMemberState) MarshalProto() ([]byte, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	out := &v1pb.PlacementState{
+		SchemaVersion:   uint32(s.SchemaVersion),
+		Index:           s.Index,
+		TableGeneration: s.TableGeneration,
+		Members:         make([]*v1pb.PlacementMember, 0, len(s.Members)),
+
+		NamespaceGenerations: s.NamespaceGenerations,
+		Aliases:              s.Aliases,
+		GenerationPending:    s.generationPending,
+		LastGenerationIndex:  s.lastGenerationIndex,
+	}
+	for _, m := range s.Members {
+		out.Members = append(out.Members, toProtoMember(m))
+	}
+	sort.Slice(out.Members, func(i, j int) bool {
+		return out.Members[i].Name < out.Members[j].Name
+	})
+	out.EntityChangedAt = toUnixNanoMap(s.EntityChangedAt)
+	out.OrphanedAt = toUnixNanoMap(s.OrphanedAt)
+	if len(s.hostSetGenerations) > 0 {
+		out.HostSetGenerations = s.hostSetGenerations
+	}
+	if s.Pins != nil {
+		out.Pins = make(map[string]*v1pb.PlacementPins, len(s.Pins))
+		for entity, pins := range s.Pins {
+			out.Pins[entity] = &v1pb.PlacementPins{Hosts: pins}
+		}
+	}
+
+	buf := proto.NewBuffer(nil)
+	buf.SetDeterministic(true)
+	if err := buf.Marshal(out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalProto replaces the persisted fields of the state with the
+// PlacementState protobuf message produced by MarshalProto and rebuilds the
+// consistent hashing tables. The state of older schema versions is migrated
+// to SchemaVersion. The runtime configuration of the state is kept. The
+// mutations of the state return ErrRestoring until the tables are rebuilt.
+func (s *DaprHostMemberState) UnmarshalProto(data []byte) error {
+	var in v1pb.PlacementState
+	if err := proto.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	members := make(map[string]*DaprHostMember, len(in.Members))
+	for _, m := range in.Members {
+		if m == nil {
+			continue
+		}
+		if _, ok := members[m.Name]; ok {
+			return errors.Errorf("duplicated member name: %s", m.Name)
+		}
+		members[m.Name] = fromProtoMember(m)
+	}
+	loaded := &DaprHostMemberState{
+		SchemaVersion:        int(in.SchemaVersion),
+		TableGeneration:      in.TableGeneration,
+		Members:              members,
+		NamespaceGenerations: in.NamespaceGenerations,
+	}
+	if err := migrateState(loaded); err != nil {
+		return err
+	}
+	var pins map[string]map[string]string
+	if in.Pins != nil {
+		pins = make(map[string]map[string]string, len(in.Pins))
+		for entity, p := range in.Pins {
+			pins[entity] = p.GetHosts()
+		}
+	}
+
+	s.lock.Lock()
+	if s.frozen {
+		s.lock.Unlock()
+		return ErrFrozen
+	}
+	// the mutations are rejected from here, so that they aren't applied to
+	// the replaced members before the tables are rebuilt from them.
+	s.restoring = true
+	s.generationPending = false
+	s.SchemaVersion = loaded.SchemaVersion
+	s.Members = members
+	s.EntityChangedAt = fromUnixNanoMap(in.EntityChangedAt)
+	s.OrphanedAt = fromUnixNanoMap(in.OrphanedAt)
+	s.NamespaceGenerations = loaded.NamespaceGenerations
+	s.Pins = pins
+	s.Aliases = in.Aliases
+	s.HostSetGenerations = in.HostSetGenerations
+	s.GenerationPending = in.GenerationPending
+	s.LastGenerationIndex = in.LastGenerationIndex
+	s.pendingRingOps = nil
+	s.tableHistory = nil
+	s.lock.Unlock()
+
+	// background context is never cancelled.
+	return s.rebuildHashingTables(context.Background(), func() {
+		s.seedGenerations(in.Index, in.TableGeneration)
+	})
+}
+
+func toProtoMember(m *DaprHostMember) *v1pb.PlacementMember {
+	p := &v1pb.PlacementMember{
+		Name:            m.Name,
+		AppId:           m.AppID,
+		Namespace:       m.Namespace,
+		Entities:        m.Entities,
+		Labels:          m.Labels,
+		Draining:        m.Draining,
+		Weight:          int64(m.Weight),
+		SkippedEntities: m.SkippedEntities,
+		Version:         m.Version,
+		RequestId:       m.RequestID,
+		CreatedAt:       toUnixNano(m.CreatedAt),
+		UpdatedAt:       toUnixNano(m.UpdatedAt),
+		DeletedAt:       toUnixNano(m.DeletedAt),
+		PlacedAt:        toUnixNano(m.PlacedAt),
+	}
+	if m.EntityWeights != nil {
+		p.EntityWeights = make(map[string]int64, len(m.EntityWeights))
+		for k, v := range m.EntityWeights {
+			p.EntityWeights[k] = int64(v)
+		}
+	}
+	return p
+}
+
+func fromProtoMember(p *v1pb.PlacementMember) *DaprHostMember {
+	m := &DaprHostMember{
+		Name:            p.Name,
+		AppID:           p.AppId,
+		Namespace:       p.Namespace,
+		Entities:        p.Entities,
+		Labels:          p.Labels,
+		Draining:        p.Draining,
+		Weight:          int(p.Weight),
+		SkippedEntities: p.SkippedEntities,
+		Version:         p.Version,
+		RequestID:       p.RequestId,
+		CreatedAt:       fromUnixNano(p.CreatedAt),
+		UpdatedAt:       fromUnixNano(p.UpdatedAt),
+		DeletedAt:       fromUnixNano(p.DeletedAt),
+		PlacedAt:        fromUnixNano(p.PlacedAt),
+	}
+	if p.EntityWeights != nil {
+		m.EntityWeights = make(map[string]int, len(p.EntityWeights))
+		for k, v := range p.EntityWeights {
+			m.EntityWeights[k] = int(v)
+		}
+	}
+	return m
+}
+
+// toUnixNano returns the unix nanoseconds of t, or zero for the zero time.
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// toUnixNanoMap returns the unix nanoseconds of the times, or nil for nil.
+func toUnixNanoMap(times map[string]time.Time) map[string]int64 {
+	if times == nil {
+		return nil
+	}
+	out := make(map[string]int64, len(times))
+	for key, t := range times {
+		out[key] = toUnixNano(t)
+	}
+	return out
+}
+
+func fromUnixNanoMap(nanos map[string]int64) map[string]time.Time {
+	if nanos == nil {
+		return nil
+	}
+	out := make(map[string]time.Time, len(nanos))
+	for key, n := range nanos {
+		out[key] = fromUnixNano(n)
+	}
+	return out
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
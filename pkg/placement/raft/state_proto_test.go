@@ -0,0 +1,148 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"testing"
+	"time"
+
+	v1pb "github.com/dapr/dapr/pkg/proto/placement/v1"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalAndUnmarshalProto(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.Index = 10
+	s.upsertMember(&DaprHostMember{
+		Name:          "127.0.0.1:8081",
+		AppID:         "FakeID_2",
+		Namespace:     "ns1",
+		Entities:      []string{"actorTypeOne"},
+		Labels:        map[string]string{"zone": "a"},
+		Weight:        2,
+		EntityWeights: map[string]int{"actorTypeOne": 3},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:  "127.0.0.1:8082",
+		AppID: "FakeID_3",
+	})
+	s.drainMember("127.0.0.1:8080")
+
+	t.Run("deterministic output", func(t *testing.T) {
+		// act
+		first, err := s.MarshalProto()
+		assert.NoError(t, err)
+		second, err := s.MarshalProto()
+		assert.NoError(t, err)
+
+		// assert
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("round trip rebuilds hashing tables", func(t *testing.T) {
+		// act
+		data, err := s.MarshalProto()
+		assert.NoError(t, err)
+		loaded := newDaprHostMemberState()
+		err = loaded.UnmarshalProto(data)
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, s.Equal(loaded))
+		assert.Equal(t, SchemaVersion, loaded.SchemaVersion)
+	})
+
+	t.Run("round trip keeps pins, aliases and checkpoints", func(t *testing.T) {
+		// arrange
+		c := s.cloneWithTables()
+		assert.NoError(t, c.AddPin("ns1/actorTypeOne", "singleton", "127.0.0.1:8081"))
+		assert.NoError(t, c.AddAlias("actorTypeOld", "ns1/actorTypeOne"))
+		orphanedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		c.OrphanedAt = map[string]time.Time{"actorTypeGone": orphanedAt}
+
+		// act
+		data, err := c.MarshalProto()
+		assert.NoError(t, err)
+		loaded := newDaprHostMemberState()
+		err = loaded.UnmarshalProto(data)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, c.Pins, loaded.Pins)
+		assert.Equal(t, c.Aliases, loaded.Aliases)
+		assert.Equal(t, c.OrphanedAt, loaded.OrphanedAt)
+		for _, key := range []string{"actorTypeOne", "ns1/actorTypeOne"} {
+			assert.Equal(t, c.EntityHostSetGeneration(key), loaded.EntityHostSetGeneration(key))
+		}
+		host, _, ok := loaded.ResolveActorHost("actorTypeOld", "singleton")
+		assert.True(t, ok)
+		assert.Equal(t, "127.0.0.1:8081", host)
+	})
+
+	t.Run("concurrent upserts are rejected or applied", func(t *testing.T) {
+		// arrange
+		data, err := s.MarshalProto()
+		assert.NoError(t, err)
+		loaded := newDaprHostMemberState()
+		done := make(chan error)
+		go func() {
+			done <- loaded.UnmarshalProto(data)
+		}()
+
+		// act
+		for i := 0; i < 100; i++ {
+			_, err := loaded.upsertMember(&DaprHostMember{
+				Name:     "127.0.0.1:9090",
+				AppID:    "FakeID_9",
+				Entities: []string{"actorTypeOne"},
+			})
+			if err != nil {
+				assert.Equal(t, ErrRestoring, err)
+			}
+		}
+
+		// assert
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("newer schema version", func(t *testing.T) {
+		// arrange
+		data, err := (&DaprHostMemberState{SchemaVersion: SchemaVersion + 1}).MarshalProto()
+		assert.NoError(t, err)
+
+		// act
+		err = newDaprHostMemberState().UnmarshalProto(data)
+
+		// assert
+		assert.Error(t, err)
+	})
+
+	t.Run("duplicated member", func(t *testing.T) {
+		// arrange
+		data, err := proto.Marshal(&v1pb.PlacementState{
+			SchemaVersion: SchemaVersion,
+			Members:       []*v1pb.PlacementMember{{Name: "127.0.0.1:8080"}, {Name: "127.0.0.1:8080"}},
+		})
+		assert.NoError(t, err)
+
+		// act
+		err = newDaprHostMemberState().UnmarshalProto(data)
+
+		// assert
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		assert.Error(t, newDaprHostMemberState().UnmarshalProto([]byte{0xff}))
+	})
+}
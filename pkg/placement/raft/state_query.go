@@ -0,0 +1,488 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+)
+
+// LastIndex returns the raft log index of the last applied command.
+func (s *DaprHostMemberState) LastIndex() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.Index
+}
+
+// setIndex sets the raft log index of the last applied command.
+func (s *DaprHostMemberState) setIndex(index uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Index = index
+
+	// the generation window closes at the first log entry after it.
+	if s.generationPending && index >= s.lastGenerationIndex+s.generationWindow {
+		s.applyTableGeneration()
+	}
+}
+
+// LastGeneration returns the current TableGeneration.
+func (s *DaprHostMemberState) LastGeneration() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.TableGeneration
+}
+
+// StateSummary is the operational summary of the state.
+type StateSummary struct {
+	// Members is the number of members.
+	Members int
+	// ActorHosts is the number of members which report Actor Types.
+	ActorHosts int
+	// Entities is the number of entities which have the hashing table.
+	Entities int
+	// TableGeneration is the generation of the hashing tables.
+	TableGeneration uint64
+	// Index is the raft log index of the last applied command.
+	Index uint64
+}
+
+// Summary returns the operational summary of the state, read at once so
+// that its fields are consistent with each other.
+func (s *DaprHostMemberState) Summary() StateSummary {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	summary := StateSummary{
+		Members:         len(s.Members),
+		Entities:        len(s.hashingTableMap),
+		TableGeneration: s.TableGeneration,
+		Index:           s.Index,
+	}
+	for _, m := range s.Members {
+		if s.isActorHost(m) {
+			summary.ActorHosts++
+		}
+	}
+	return summary
+}
+
+// EntityHostCount returns the number of hosts in the hashing table per entity.
+func (s *DaprHostMemberState) EntityHostCount() map[string]int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	counts := make(map[string]int, len(s.hashingTableMap))
+	for e := range s.hashingTableMap {
+		counts[e] = s.entityHostCount(e)
+	}
+	return counts
+}
+
+// entityHostCount returns the number of hosts in the hashing table of the key.
+// The caller must hold the lock.
+func (s *DaprHostMemberState) entityHostCount(key string) int {
+	if t, ok := s.hashingTableMap[key]; ok {
+		return t.HostCount()
+	}
+	return 0
+}
+
+// HostServesEntity returns true if the host is in the hashing table of the
+// Actor Type. entity is the hashing table key built by EntityKey.
+func (s *DaprHostMemberState) HostServesEntity(name, entity string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.hashingTableMap[entity]
+	return ok && t.Contains(name)
+}
+
+// TotalVirtualNodes returns the number of virtual nodes in all hashing tables.
+func (s *DaprHostMemberState) TotalVirtualNodes() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	total := 0
+	for _, t := range s.hashingTableMap {
+		total += t.VirtualNodeCount()
+	}
+	return total
+}
+
+// SetMinReplicas sets the minimum number of hosts per hashing table key
+// which UnderReplicatedEntities checks.
+func (s *DaprHostMemberState) SetMinReplicas(minReplicas map[string]int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.minReplicas = make(map[string]int, len(minReplicas))
+	for k, v := range minReplicas {
+		s.minReplicas[k] = v
+	}
+}
+
+// UnderReplicatedEntities returns the current number of hosts of the entities
+// whose hashing table has fewer hosts than the configured minimum. Entities
+// without hashing table are reported with zero hosts.
+func (s *DaprHostMemberState) UnderReplicatedEntities() map[string]int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	under := map[string]int{}
+	for e, minCount := range s.minReplicas {
+		if count := s.entityHostCount(e); count < minCount {
+			under[e] = count
+		}
+	}
+	return under
+}
+
+// TotalEntities returns the number of entities which have the hashing table.
+func (s *DaprHostMemberState) TotalEntities() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.hashingTableMap)
+}
+
+// ActorHosts returns the copies of members which report Actor Types, sorted
+// by name.
+func (s *DaprHostMemberState) ActorHosts() []*DaprHostMember {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.sortedMembersWhere(s.isActorHost)
+}
+
+// NonActorHosts returns the copies of members which report no Actor Types,
+// sorted by name.
+func (s *DaprHostMemberState) NonActorHosts() []*DaprHostMember {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.sortedMembersWhere(func(m *DaprHostMember) bool {
+		return !s.isActorHost(m)
+	})
+}
+
+// HostCounts returns the number of members which report Actor Types and the
+// number of the other members.
+func (s *DaprHostMemberState) HostCounts() (actorHosts, nonActorHosts int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, m := range s.Members {
+		if s.isActorHost(m) {
+			actorHosts++
+		} else {
+			nonActorHosts++
+		}
+	}
+	return actorHosts, nonActorHosts
+}
+
+// sortedMembersWhere returns the copies of members which satisfy pred, sorted
+// by name. The caller must hold the lock.
+func (s *DaprHostMemberState) sortedMembersWhere(pred func(*DaprHostMember) bool) []*DaprHostMember {
+	members := []*DaprHostMember{}
+	for _, m := range s.Members {
+		if pred(m) {
+			members = append(members, m.clone())
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Name < members[j].Name
+	})
+	return members
+}
+
+// MembersByLabel returns the copies of members which have the label with the
+// given value, sorted by name.
+func (s *DaprHostMemberState) MembersByLabel(key, value string) []*DaprHostMember {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.sortedMembersWhere(func(m *DaprHostMember) bool {
+		v, ok := m.Labels[key]
+		return ok && v == value
+	})
+}
+
+// HostEntities returns the sorted hashing table keys whose tables currently
+// contain the host. It returns an empty slice for unknown or draining hosts.
+func (s *DaprHostMemberState) HostEntities(name string) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	entities := []string{}
+	for e, t := range s.hashingTableMap {
+		_, _, loadMap, _ := t.GetInternals()
+		if _, ok := loadMap[name]; ok {
+			entities = append(entities, e)
+		}
+	}
+	sort.Strings(entities)
+	return entities
+}
+
+// EntityHosts returns the app id of each host in the hashing table of the key,
+// so that the placement tables can be disseminated without looking up the
+// members. ok is false when the table doesn't exist.
+func (s *DaprHostMemberState) EntityHosts(entity string) (map[string]string, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return nil, false
+	}
+	return t.HostMap(), true
+}
+
+// ForEachEntity calls fn with each key of the hashing tables, in sorted
+// order, and a copy of the map from the names to the AppIDs of the hosts in
+// the table, until fn returns false. The maps are copied up front under the
+// read lock, so fn sees the tables as of the call and may call back into the
+// state.
+func (s *DaprHostMemberState) ForEachEntity(fn func(entity string, hosts map[string]string) bool) {
+	s.lock.RLock()
+	entities := make([]string, 0, len(s.hashingTableMap))
+	hosts := make(map[string]map[string]string, len(s.hashingTableMap))
+	for key, t := range s.hashingTableMap {
+		entities = append(entities, key)
+		hosts[key] = t.HostMap()
+	}
+	s.lock.RUnlock()
+
+	sort.Strings(entities)
+	for _, e := range entities {
+		if !fn(e, hosts[e]) {
+			return
+		}
+	}
+}
+
+// SnapshotMembers returns value copies of all members. The returned members
+// don't share any slice or map with the state.
+func (s *DaprHostMemberState) SnapshotMembers() map[string]DaprHostMember {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	members := make(map[string]DaprHostMember, len(s.Members))
+	for k, m := range s.Members {
+		members[k] = *m.clone()
+	}
+	return members
+}
+
+// MemberCount returns the number of members.
+func (s *DaprHostMemberState) MemberCount() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.Members)
+}
+
+// ListMembers returns value copies of the page of members sorted by name,
+// starting at offset and holding at most limit members, and the total number
+// of members. A negative offset is treated as zero and a non-positive limit
+// returns all members from offset.
+func (s *DaprHostMemberState) ListMembers(offset, limit int) ([]DaprHostMember, int) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	names := sortedMemberNames(s.Members)
+	total := len(names)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && limit < total-offset {
+		end = offset + limit
+	}
+
+	page := make([]DaprHostMember, 0, end-offset)
+	for _, name := range names[offset:end] {
+		page = append(page, *s.Members[name].clone())
+	}
+	return page, total
+}
+
+// RingStats is the spread of the hash space coverage across the hosts in a
+// hashing table.
+type RingStats struct {
+	// Hosts is the number of hosts in the table.
+	Hosts int
+	// StdDev is the standard deviation of the hosts' shares of the hash space.
+	StdDev float64
+	// MinShare and MaxShare are the smallest and largest shares in basis
+	// points.
+	MinShare int
+	MaxShare int
+}
+
+// HostSpec is a hypothetical host for SimulateAddHosts. Weight is the weight
+// of the host in the hashing table, where zero is the default weight.
+type HostSpec struct {
+	Name   string
+	Weight int
+}
+
+// RingStats returns the spread of the hash space coverage across the hosts in
+// the hashing table of the key. stddev is the standard deviation of the hosts'
+// shares of the hash space, minShare and maxShare are the smallest and largest
+// shares in basis points. ok is false when the table doesn't exist.
+func (s *DaprHostMemberState) RingStats(entity string) (stddev float64, minShare, maxShare int, ok bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	stats := coverageStats(t.Coverage())
+	return stats.StdDev, stats.MinShare, stats.MaxShare, true
+}
+
+// SimulateAddHosts returns the RingStats of the hashing table of the key if
+// the hypothetical hosts were added to it, such as to plan the scaling of an
+// actor type. The hosts are added to a copy of the table, which is empty if
+// the table doesn't exist, and hosts already in the table are ignored. The
+// state is unchanged.
+func (s *DaprHostMemberState) SimulateAddHosts(entity string, specs []HostSpec) RingStats {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t := s.newHashingTable()
+	defer hashingTablePool.Put(t)
+	if existing, ok := s.hashingTableMap[entity]; ok {
+		_, _, loadMap, _ := existing.GetInternals()
+		for name, h := range loadMap {
+			t.AddWithWeight(name, h.AppID, 0, h.Weight)
+		}
+	}
+
+	for _, spec := range specs {
+		t.AddWithWeight(spec.Name, "", 0, spec.Weight)
+	}
+	return coverageStats(t.Coverage())
+}
+
+// coverageStats returns the RingStats of the shares of the hash space of the
+// hosts.
+func coverageStats(coverage map[string]float64) RingStats {
+	stats := RingStats{Hosts: len(coverage)}
+	if len(coverage) == 0 {
+		return stats
+	}
+
+	mean := 1 / float64(len(coverage))
+	stats.MinShare = math.MaxInt32
+	for _, c := range coverage {
+		stats.StdDev += (c - mean) * (c - mean)
+		bp := int(math.Round(c * 10000))
+		if bp < stats.MinShare {
+			stats.MinShare = bp
+		}
+		if bp > stats.MaxShare {
+			stats.MaxShare = bp
+		}
+	}
+	stats.StdDev = math.Sqrt(stats.StdDev / float64(len(coverage)))
+	return stats
+}
+
+// EntityRing returns the virtual nodes of the hashing table of the key sorted
+// by hash. ok is false when the table doesn't exist.
+func (s *DaprHostMemberState) EntityRing(entity string) ([]hashing.RingPoint, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return nil, false
+	}
+	return t.Ring(), true
+}
+
+// RebalanceRatio returns the fraction of the hash space of the hashing table
+// of the key which would be reassigned if the hypothetical host were added
+// with the default weight. Consistent hashing moves about 1/(K+1) of the
+// hash space when a host joins a table of K hosts, so a much larger ratio
+// indicates a degenerate ring. The ratio is zero if the host is already in
+// the table and one if the table doesn't exist. The state is unchanged.
+func (s *DaprHostMemberState) RebalanceRatio(entity string, hypotheticalHost string) float64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	// both rings are built with the current configuration, so that only the
+	// hypothetical host differs between them.
+	t := s.newHashingTable()
+	defer hashingTablePool.Put(t)
+	if existing, ok := s.hashingTableMap[entity]; ok {
+		_, _, loadMap, _ := existing.GetInternals()
+		for name, h := range loadMap {
+			t.AddWithWeight(name, h.AppID, 0, h.Weight)
+		}
+	}
+
+	before := t.Ring()
+	t.Add(hypotheticalHost, "", 0)
+	return hashing.ReassignedFraction(before, t.Ring())
+}
+
+// AgeBuckets returns the number of members per age bucket, where the age of a
+// member is now - CreatedAt. Each bucket is keyed by its lower boundary and
+// counts the members at least as old as the boundary and younger than the next
+// larger boundary. Members younger than the smallest boundary are not counted,
+// so buckets usually start with zero.
+func (s *DaprHostMemberState) AgeBuckets(now time.Time, buckets []time.Duration) map[time.Duration]int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	bounds := make([]time.Duration, len(buckets))
+	copy(bounds, buckets)
+	sort.Slice(bounds, func(i, j int) bool {
+		return bounds[i] < bounds[j]
+	})
+
+	counts := make(map[time.Duration]int, len(bounds))
+	for _, b := range bounds {
+		counts[b] = 0
+	}
+	for _, m := range s.Members {
+		age := now.Sub(m.CreatedAt)
+		// index of the first boundary larger than age.
+		i := sort.Search(len(bounds), func(i int) bool {
+			return bounds[i] > age
+		})
+		if i > 0 {
+			counts[bounds[i-1]]++
+		}
+	}
+	return counts
+}
+
+// EntityCoverage returns the contiguous hash ranges owned by each host in the
+// hashing table of the key. ok is false when the table doesn't exist.
+func (s *DaprHostMemberState) EntityCoverage(entity string) (map[string][]hashing.Range, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.hashingTableMap[entity]
+	if !ok {
+		return nil, false
+	}
+	return t.HostRanges(), true
+}
@@ -0,0 +1,723 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityHostCount(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	t.Run("count hosts per entity", func(t *testing.T) {
+		assert.Equal(t, map[string]int{"actorTypeOne": 2, "actorTypeTwo": 1}, s.EntityHostCount())
+		assert.Equal(t, 2, s.TotalEntities())
+	})
+
+	t.Run("counts reflect removal and expiry", func(t *testing.T) {
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+		s.Members["127.0.0.1:8080"].UpdatedAt = time.Now().UTC().Add(-time.Hour)
+		s.expireStaleMembers(time.Minute)
+
+		// assert
+		assert.Equal(t, map[string]int{}, s.EntityHostCount())
+		assert.Equal(t, 0, s.TotalEntities())
+	})
+}
+
+func TestMembersByLabel(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:   "127.0.0.1:8081",
+		AppID:  "FakeID_2",
+		Labels: map[string]string{"region": "west"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+		Labels:   map[string]string{"region": "west", "zone": "1"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:   "127.0.0.1:8082",
+		AppID:  "FakeID_3",
+		Labels: map[string]string{"region": "east"},
+	})
+
+	t.Run("matching members are sorted", func(t *testing.T) {
+		// act
+		members := s.MembersByLabel("region", "west")
+
+		// assert
+		assert.Equal(t, 2, len(members))
+		assert.Equal(t, "127.0.0.1:8080", members[0].Name)
+		assert.Equal(t, "127.0.0.1:8081", members[1].Name)
+	})
+
+	t.Run("returns copies", func(t *testing.T) {
+		// act
+		members := s.MembersByLabel("zone", "1")
+		members[0].Labels["zone"] = "2"
+
+		// assert
+		assert.Equal(t, "1", s.Members["127.0.0.1:8080"].Labels["zone"])
+	})
+
+	t.Run("no matching members", func(t *testing.T) {
+		assert.Empty(t, s.MembersByLabel("region", "north"))
+	})
+}
+
+func TestHostEntities(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeTwo", "actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:      "127.0.0.1:8081",
+		AppID:     "FakeID_2",
+		Namespace: "ns1",
+		Entities:  []string{"actorTypeOne"},
+	})
+
+	t.Run("sorted entities of the host", func(t *testing.T) {
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, s.HostEntities("127.0.0.1:8080"))
+		assert.Equal(t, []string{"ns1/actorTypeOne"}, s.HostEntities("127.0.0.1:8081"))
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		entities := s.HostEntities("127.0.0.1:9999")
+		assert.NotNil(t, entities)
+		assert.Empty(t, entities)
+	})
+
+	t.Run("draining host", func(t *testing.T) {
+		s.drainMember("127.0.0.1:8080")
+		entities := s.HostEntities("127.0.0.1:8080")
+		assert.NotNil(t, entities)
+		assert.Empty(t, entities)
+	})
+}
+
+func TestEntityHosts(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	t.Run("hosts with app ids", func(t *testing.T) {
+		// act
+		hosts, ok := s.EntityHosts("actorTypeOne")
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, map[string]string{"127.0.0.1:8080": "FakeID", "127.0.0.1:8081": "FakeID_2"}, hosts)
+	})
+
+	t.Run("returned map is a copy", func(t *testing.T) {
+		// act
+		hosts, _ := s.EntityHosts("actorTypeTwo")
+		hosts["127.0.0.1:9999"] = "Changed"
+
+		// assert
+		again, _ := s.EntityHosts("actorTypeTwo")
+		assert.Equal(t, map[string]string{"127.0.0.1:8080": "FakeID"}, again)
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		// act
+		hosts, ok := s.EntityHosts("actorTypeThree")
+
+		// assert
+		assert.False(t, ok)
+		assert.Nil(t, hosts)
+	})
+}
+
+func TestSnapshotMembers(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+		Labels:   map[string]string{"region": "west"},
+	})
+
+	// act
+	members := s.SnapshotMembers()
+	m := members["127.0.0.1:8080"]
+	m.Entities[0] = "actorTypeTwo"
+	m.Labels["region"] = "east"
+
+	// assert
+	assert.Equal(t, 1, len(members))
+	assert.Equal(t, "FakeID", m.AppID)
+	assert.Equal(t, "actorTypeOne", s.Members["127.0.0.1:8080"].Entities[0])
+	assert.Equal(t, "west", s.Members["127.0.0.1:8080"].Labels["region"])
+}
+
+func TestMemberCount(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	assert.Equal(t, 0, s.MemberCount())
+
+	// act
+	s.upsertMember(&DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID"})
+	s.upsertMember(&DaprHostMember{Name: "127.0.0.1:8081", AppID: "FakeID"})
+
+	// assert
+	assert.Equal(t, 2, s.MemberCount())
+}
+
+func TestListMembers(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	for _, i := range []int{3, 0, 4, 1, 2} {
+		s.upsertMember(&DaprHostMember{
+			Name:  fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID: "FakeID",
+		})
+	}
+	names := func(members []DaprHostMember) []string {
+		result := []string{}
+		for _, m := range members {
+			result = append(result, m.Name)
+		}
+		return result
+	}
+
+	testcases := []struct {
+		name     string
+		offset   int
+		limit    int
+		expected []string
+	}{
+		{"first page", 0, 2, []string{"127.0.0.1:8080", "127.0.0.1:8081"}},
+		{"middle page", 2, 2, []string{"127.0.0.1:8082", "127.0.0.1:8083"}},
+		{"last partial page", 4, 2, []string{"127.0.0.1:8084"}},
+		{"beyond the end", 10, 2, []string{}},
+		{"negative offset", -1, 1, []string{"127.0.0.1:8080"}},
+		{"no limit", 3, 0, []string{"127.0.0.1:8083", "127.0.0.1:8084"}},
+		{"limit up to the end", 3, 2, []string{"127.0.0.1:8083", "127.0.0.1:8084"}},
+		{"maximum limit", 1, math.MaxInt64, []string{"127.0.0.1:8081", "127.0.0.1:8082", "127.0.0.1:8083", "127.0.0.1:8084"}},
+		{"maximum limit beyond the end", math.MaxInt64, math.MaxInt64, []string{}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// act
+			page, total := s.ListMembers(tc.offset, tc.limit)
+
+			// assert
+			assert.Equal(t, tc.expected, names(page))
+			assert.Equal(t, 5, total)
+		})
+	}
+
+	t.Run("page is a copy", func(t *testing.T) {
+		// act
+		page, _ := s.ListMembers(0, 1)
+		page[0].AppID = "Changed"
+
+		// assert
+		assert.Equal(t, "FakeID", s.Members["127.0.0.1:8080"].AppID)
+	})
+}
+
+func TestUnderReplicatedEntities(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.SetMinReplicas(map[string]int{
+		"actorTypeOne":   2,
+		"actorTypeTwo":   1,
+		"actorTypeThree": 1,
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+
+	t.Run("report under replicated entities", func(t *testing.T) {
+		assert.Equal(t, map[string]int{"actorTypeOne": 1, "actorTypeThree": 0}, s.UnderReplicatedEntities())
+	})
+
+	t.Run("satisfied after adding replicas", func(t *testing.T) {
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeOne", "actorTypeThree"},
+		})
+
+		assert.Empty(t, s.UnderReplicatedEntities())
+	})
+}
+
+func TestRingStats(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(100)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	for i := 0; i < 3; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    fmt.Sprintf("FakeID_%d", i),
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+
+	t.Run("balanced ring", func(t *testing.T) {
+		// act
+		stddev, minShare, maxShare, ok := s.RingStats("actorTypeOne")
+
+		// assert
+		assert.True(t, ok)
+		assert.True(t, stddev < 0.1)
+		assert.True(t, minShare > 0)
+		assert.True(t, minShare <= 3334)
+		assert.True(t, maxShare >= 3333)
+		assert.True(t, maxShare < 10000)
+	})
+
+	t.Run("weighted host skews the ring", func(t *testing.T) {
+		balanced, _, _, _ := s.RingStats("actorTypeOne")
+
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID_0",
+			Entities: []string{"actorTypeOne"},
+			Weight:   4,
+		})
+		stddev, _, maxShare, ok := s.RingStats("actorTypeOne")
+
+		// assert
+		assert.True(t, ok)
+		assert.True(t, stddev > balanced)
+		assert.True(t, maxShare > 5000)
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		_, _, _, ok := s.RingStats("actorTypeTwo")
+		assert.False(t, ok)
+	})
+}
+
+func TestForEachEntity(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeTwo", "actorTypeOne", "actorTypeThree"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	t.Run("entities in sorted order", func(t *testing.T) {
+		entities := []string{}
+		hosts := map[string]map[string]string{}
+
+		// act
+		s.ForEachEntity(func(entity string, h map[string]string) bool {
+			entities = append(entities, entity)
+			hosts[entity] = h
+			return true
+		})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeThree", "actorTypeTwo"}, entities)
+		assert.Equal(t, map[string]string{"127.0.0.1:8080": "FakeID", "127.0.0.1:8081": "FakeID_2"}, hosts["actorTypeOne"])
+		assert.Equal(t, map[string]string{"127.0.0.1:8080": "FakeID"}, hosts["actorTypeTwo"])
+	})
+
+	t.Run("stops when fn returns false", func(t *testing.T) {
+		entities := []string{}
+
+		// act
+		s.ForEachEntity(func(entity string, h map[string]string) bool {
+			entities = append(entities, entity)
+			return len(entities) < 2
+		})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeThree"}, entities)
+	})
+
+	t.Run("fn may change the state", func(t *testing.T) {
+		// act
+		s.ForEachEntity(func(entity string, h map[string]string) bool {
+			h["127.0.0.1:9999"] = "Changed"
+			s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+			return true
+		})
+
+		// assert
+		hosts, _ := s.EntityHosts("actorTypeOne")
+		assert.Equal(t, map[string]string{"127.0.0.1:8080": "FakeID"}, hosts)
+	})
+}
+
+func TestSimulateAddHosts(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(100)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	for i := 0; i < 3; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    fmt.Sprintf("FakeID_%d", i),
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+	stddev, minShare, maxShare, _ := s.RingStats("actorTypeOne")
+
+	t.Run("no hypothetical hosts", func(t *testing.T) {
+		// act
+		stats := s.SimulateAddHosts("actorTypeOne", nil)
+
+		// assert
+		assert.Equal(t, RingStats{Hosts: 3, StdDev: stddev, MinShare: minShare, MaxShare: maxShare}, stats)
+	})
+
+	t.Run("hypothetical hosts are added to a copy", func(t *testing.T) {
+		// act
+		stats := s.SimulateAddHosts("actorTypeOne", []HostSpec{
+			{Name: "127.0.0.1:8090"},
+			{Name: "127.0.0.1:8091", Weight: 4},
+			{Name: "127.0.0.1:8080"},
+		})
+
+		// assert
+		assert.Equal(t, 5, stats.Hosts)
+		assert.True(t, stats.MaxShare > 3333)
+		assert.True(t, stats.MinShare < minShare)
+		assert.Len(t, s.hashingTableMap["actorTypeOne"].Hosts(), 3)
+		after, _, _, _ := s.RingStats("actorTypeOne")
+		assert.Equal(t, stddev, after)
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		// act
+		stats := s.SimulateAddHosts("actorTypeTwo", []HostSpec{{Name: "127.0.0.1:8090"}})
+
+		// assert
+		assert.Equal(t, RingStats{Hosts: 1, MinShare: 10000, MaxShare: 10000}, stats)
+		_, ok := s.hashingTableMap["actorTypeTwo"]
+		assert.False(t, ok)
+	})
+}
+
+func TestRebalanceRatio(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(100)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	for i := 0; i < 4; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    fmt.Sprintf("FakeID_%d", i),
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+	generation := s.TableGeneration
+
+	t.Run("new host moves about 1/(K+1)", func(t *testing.T) {
+		// act
+		ratio := s.RebalanceRatio("actorTypeOne", "127.0.0.1:8084")
+
+		// assert
+		assert.InDelta(t, 0.2, ratio, 0.1)
+		assert.Equal(t, generation, s.TableGeneration)
+		assert.Equal(t, 4, s.hashingTableMap["actorTypeOne"].HostCount())
+	})
+
+	t.Run("existing host moves nothing", func(t *testing.T) {
+		// act
+		ratio := s.RebalanceRatio("actorTypeOne", "127.0.0.1:8080")
+
+		// assert
+		assert.Equal(t, 0.0, ratio)
+	})
+
+	t.Run("unknown entity moves everything", func(t *testing.T) {
+		// act
+		ratio := s.RebalanceRatio("actorTypeTwo", "127.0.0.1:8080")
+
+		// assert
+		assert.Equal(t, 1.0, ratio)
+		assert.NotContains(t, s.hashingTableMap, "actorTypeTwo")
+	})
+}
+
+func TestEntityRing(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	t.Run("ring of entity", func(t *testing.T) {
+		// act
+		ring, ok := s.EntityRing("actorTypeOne")
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, 10, len(ring))
+		for _, p := range ring {
+			assert.Equal(t, "127.0.0.1:8080", p.Host)
+		}
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		ring, ok := s.EntityRing("actorTypeTwo")
+		assert.False(t, ok)
+		assert.Nil(t, ring)
+	})
+}
+
+func TestAgeBuckets(t *testing.T) {
+	// arrange
+	now := time.Now().UTC()
+	s := newDaprHostMemberState()
+	for i, age := range []time.Duration{time.Second, 2 * time.Minute, 3 * time.Minute, 2 * time.Hour, -time.Minute} {
+		name := fmt.Sprintf("127.0.0.1:808%d", i)
+		s.upsertMember(&DaprHostMember{Name: name, AppID: "FakeID"})
+		s.Members[name].CreatedAt = now.Add(-age)
+	}
+
+	// act
+	buckets := s.AgeBuckets(now, []time.Duration{time.Hour, 0, time.Minute})
+
+	// assert
+	assert.Equal(t, map[time.Duration]int{
+		0:           1,
+		time.Minute: 2,
+		time.Hour:   1,
+	}, buckets)
+}
+
+func TestTotalVirtualNodes(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		Weight:   2,
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	// act
+	total := s.TotalVirtualNodes()
+
+	// assert
+	assert.Equal(t, 50, total)
+}
+
+func TestLastIndexAndGeneration(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.Index = 5
+
+	// act
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	// assert
+	assert.Equal(t, uint64(5), s.LastIndex())
+	assert.Equal(t, uint64(1), s.LastGeneration())
+}
+
+func TestEntityCoverage(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	t.Run("ranges per host", func(t *testing.T) {
+		// act
+		coverage, ok := s.EntityCoverage("actorTypeOne")
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, 2, len(coverage))
+		assert.NotEmpty(t, coverage["127.0.0.1:8080"])
+		assert.NotEmpty(t, coverage["127.0.0.1:8081"])
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		_, ok := s.EntityCoverage("actorTypeTwo")
+		assert.False(t, ok)
+	})
+}
+
+func TestSummary(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.Index = 7
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:      "127.0.0.1:8081",
+		AppID:     "FakeID_2",
+		Namespace: "ns1",
+		Entities:  []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:  "127.0.0.1:8082",
+		AppID: "FakeID_3",
+	})
+
+	// act
+	summary := s.Summary()
+
+	// assert
+	assert.Equal(t, StateSummary{
+		Members:         3,
+		ActorHosts:      2,
+		Entities:        3,
+		TableGeneration: 2,
+		Index:           7,
+	}, summary)
+}
+
+func TestActorHosts(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:  "127.0.0.1:8082",
+		AppID: "FakeID_3",
+	})
+
+	t.Run("actor hosts", func(t *testing.T) {
+		// act
+		hosts := s.ActorHosts()
+
+		// assert
+		assert.Len(t, hosts, 2)
+		assert.Equal(t, "127.0.0.1:8080", hosts[0].Name)
+		assert.Equal(t, "127.0.0.1:8081", hosts[1].Name)
+		assert.NotSame(t, s.Members["127.0.0.1:8080"], hosts[0])
+	})
+
+	t.Run("non actor hosts", func(t *testing.T) {
+		// act
+		hosts := s.NonActorHosts()
+
+		// assert
+		assert.Len(t, hosts, 1)
+		assert.Equal(t, "127.0.0.1:8082", hosts[0].Name)
+	})
+
+	t.Run("counts", func(t *testing.T) {
+		// act
+		actorHosts, nonActorHosts := s.HostCounts()
+
+		// assert
+		assert.Equal(t, 2, actorHosts)
+		assert.Equal(t, 1, nonActorHosts)
+	})
+}
+
+func TestHostServesEntity(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:      "127.0.0.1:8080",
+		AppID:     "FakeID",
+		Namespace: "ns1",
+		Entities:  []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeTwo"},
+	})
+
+	// assert
+	assert.True(t, s.HostServesEntity("127.0.0.1:8080", EntityKey("ns1", "actorTypeOne")))
+	assert.False(t, s.HostServesEntity("127.0.0.1:8080", "actorTypeOne"))
+	assert.False(t, s.HostServesEntity("127.0.0.1:8080", "actorTypeTwo"))
+	assert.True(t, s.HostServesEntity("127.0.0.1:8081", "actorTypeTwo"))
+	assert.False(t, s.HostServesEntity("127.0.0.1:9999", "actorTypeTwo"))
+}
@@ -6,9 +6,14 @@
 package raft
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/dapr/dapr/pkg/placement/hashing"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,19 +46,52 @@ func TestClone(t *testing.T) {
 	assert.EqualValues(t, s.Members, newState.Members)
 }
 
+func TestCloneWithTables(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.SetReplicationFactor(20)
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	// act
+	newState := s.cloneWithTables()
+	host, _, ok := newState.ResolveActorHost("actorTypeOne", "actor1")
+
+	// assert
+	assert.NotSame(t, s, newState)
+	assert.True(t, s.Equal(newState))
+	assert.Equal(t, s.TotalVirtualNodes(), newState.TotalVirtualNodes())
+	assert.True(t, ok)
+	expected, _, _ := s.ResolveActorHost("actorTypeOne", "actor1")
+	assert.Equal(t, expected, host)
+	assert.NotSame(t, s.hashingTableMap["actorTypeOne"], newState.hashingTableMap["actorTypeOne"])
+}
+
 func TestUpsertMember(t *testing.T) {
 	// arrange
 	s := newDaprHostMemberState()
 
 	t.Run("add new actor member", func(t *testing.T) {
 		// act
-		updated := s.upsertMember(&DaprHostMember{
+		updated, err := s.upsertMember(&DaprHostMember{
 			Name:     "127.0.0.1:8080",
 			AppID:    "FakeID",
 			Entities: []string{"actorTypeOne", "actorTypeTwo"},
 		})
 
 		// assert
+		assert.NoError(t, err)
 		assert.Equal(t, 1, len(s.Members))
 		assert.Equal(t, 2, len(s.hashingTableMap))
 		assert.True(t, updated)
@@ -61,25 +99,27 @@ func TestUpsertMember(t *testing.T) {
 
 	t.Run("add non actor member", func(t *testing.T) {
 		// act
-		updated := s.upsertMember(&DaprHostMember{
+		updated, err := s.upsertMember(&DaprHostMember{
 			Name:     "127.0.0.1:8081",
 			AppID:    "FakeID_2",
 			Entities: []string{},
 		})
 
 		// assert
+		assert.NoError(t, err)
 		assert.Equal(t, 2, len(s.Members))
 		assert.Equal(t, 2, len(s.hashingTableMap))
 		assert.False(t, updated)
 
 		// act
-		updated = s.upsertMember(&DaprHostMember{
+		updated, err = s.upsertMember(&DaprHostMember{
 			Name:     "127.0.0.1:8081",
 			AppID:    "FakeID_2",
 			Entities: []string{},
 		})
 
 		// assert
+		assert.NoError(t, err)
 		assert.False(t, updated)
 	})
 
@@ -98,9 +138,10 @@ func TestUpsertMember(t *testing.T) {
 		//
 		// this tries to update the existing actor members.
 		// it will delete empty consistent hashing table.
-		updated := s.upsertMember(testMember)
+		updated, err := s.upsertMember(testMember)
 
 		// assert
+		assert.NoError(t, err)
 		assert.Equal(t, 2, len(s.Members))
 		assert.True(t, updated)
 		assert.Equal(t, 1, len(s.Members[testMember.Name].Entities))
@@ -116,19 +157,20 @@ func TestRemoveMember(t *testing.T) {
 
 	t.Run("remove member and clean up consistent hashing table", func(t *testing.T) {
 		// act
-		updated := s.upsertMember(&DaprHostMember{
+		updated, err := s.upsertMember(&DaprHostMember{
 			Name:     "127.0.0.1:8080",
 			AppID:    "FakeID",
 			Entities: []string{"actorTypeOne", "actorTypeTwo"},
 		})
 
 		// assert
+		assert.NoError(t, err)
 		assert.Equal(t, 1, len(s.Members))
 		assert.True(t, updated)
 		assert.Equal(t, 2, len(s.hashingTableMap))
 
 		// act
-		updated = s.removeMember(&DaprHostMember{
+		updated, _ = s.removeMember(&DaprHostMember{
 			Name: "127.0.0.1:8080",
 		})
 
@@ -140,19 +182,20 @@ func TestRemoveMember(t *testing.T) {
 
 	t.Run("no table update required", func(t *testing.T) {
 		// act
-		updated := s.upsertMember(&DaprHostMember{
+		updated, err := s.upsertMember(&DaprHostMember{
 			Name:     "127.0.0.1:8080",
 			AppID:    "FakeID",
 			Entities: []string{},
 		})
 
 		// assert
+		assert.NoError(t, err)
 		assert.Equal(t, 1, len(s.Members))
 		assert.False(t, updated)
 		assert.Equal(t, 0, len(s.hashingTableMap))
 
 		// act
-		updated = s.removeMember(&DaprHostMember{
+		updated, _ = s.removeMember(&DaprHostMember{
 			Name: "127.0.0.1:8080",
 		})
 
@@ -264,3 +307,3026 @@ func TestRestoreHashingTables(t *testing.T) {
 	// assert
 	assert.Equal(t, 2, len(s.hashingTableMap))
 }
+
+func TestRestoreHashingTablesIdenticalRings(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	for i := 19; i >= 0; i-- {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:%d", 8000+i),
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", fmt.Sprintf("actorType%d", i%3)},
+			Weight:   i % 4,
+		})
+	}
+	s.removeMember(&DaprHostMember{Name: "127.0.0.1:8007"})
+
+	for i := 0; i < 5; i++ {
+		// act
+		c := s.clone()
+		c.restoreHashingTables()
+
+		// assert
+		assert.Equal(t, len(s.hashingTableMap), len(c.hashingTableMap))
+		for key, ring := range s.hashingTableMap {
+			restored, ok := c.hashingTableMap[key]
+			assert.True(t, ok)
+			wantHosts, wantSet, wantLoads, _ := ring.GetInternals()
+			hosts, sortedSet, loads, _ := restored.GetInternals()
+			assert.Equal(t, wantHosts, hosts)
+			assert.Equal(t, wantSet, sortedSet)
+			assert.Equal(t, wantLoads, loads)
+			assert.Equal(t, ring.Ring(), restored.Ring())
+		}
+	}
+}
+
+func TestUpsertMemberWeight(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	s := newDaprHostMemberState()
+	testMember := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	}
+	s.upsertMember(testMember)
+	_, sortedSet, _, _ := s.hashingTableMap["actorTypeOne"].GetInternals()
+	assert.Equal(t, 10, len(sortedSet))
+
+	// act
+	testMember.Weight = 3
+	updated, err := s.upsertMember(testMember)
+
+	// assert
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, 3, s.Members[testMember.Name].Weight)
+	_, sortedSet, _, _ = s.hashingTableMap["actorTypeOne"].GetInternals()
+	assert.Equal(t, 30, len(sortedSet))
+}
+
+func TestExpireStaleMembers(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8082",
+		AppID:    "FakeID_3",
+		Entities: []string{"actorTypeOne"},
+	})
+	gen := s.TableGeneration
+
+	old := time.Now().UTC().Add(-time.Minute)
+	s.Members["127.0.0.1:8080"].UpdatedAt = old
+	s.Members["127.0.0.1:8081"].UpdatedAt = old
+
+	t.Run("evict stale members", func(t *testing.T) {
+		// act
+		expired := s.expireStaleMembers(10 * time.Second)
+
+		// assert
+		assert.Equal(t, []string{"127.0.0.1:8080", "127.0.0.1:8081"}, expired)
+		assert.Equal(t, 1, len(s.Members))
+		assert.Equal(t, 1, len(s.hashingTableMap))
+		assert.Equal(t, gen+1, s.TableGeneration)
+	})
+
+	t.Run("nothing to evict", func(t *testing.T) {
+		// act
+		expired := s.expireStaleMembers(10 * time.Second)
+
+		// assert
+		assert.Equal(t, 0, len(expired))
+		assert.Equal(t, 1, len(s.Members))
+		assert.Equal(t, gen+1, s.TableGeneration)
+	})
+}
+
+func TestNamespaceScopedHashingTables(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+
+	t.Run("same actor type in different namespaces", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+		s.upsertMember(&DaprHostMember{
+			Name:      "127.0.0.1:8081",
+			AppID:     "FakeID",
+			Namespace: "ns1",
+			Entities:  []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, 2, len(s.hashingTableMap))
+		assert.Equal(t, []string{"127.0.0.1:8080"}, s.hashingTableMap["actorTypeOne"].Hosts())
+		assert.Equal(t, []string{"127.0.0.1:8081"}, s.hashingTableMap[EntityKey("ns1", "actorTypeOne")].Hosts())
+	})
+
+	t.Run("namespace change moves host to another table", func(t *testing.T) {
+		// act
+		updated, err := s.upsertMember(&DaprHostMember{
+			Name:      "127.0.0.1:8081",
+			AppID:     "FakeID",
+			Namespace: "ns2",
+			Entities:  []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, 2, len(s.hashingTableMap))
+		assert.NotNil(t, s.hashingTableMap["ns2/actorTypeOne"])
+	})
+
+	t.Run("separator in actor type or namespace is rejected", func(t *testing.T) {
+		for _, m := range []*DaprHostMember{
+			{Name: "127.0.0.1:8082", AppID: "FakeID", Entities: []string{"ns1/actorTypeOne"}},
+			{Name: "127.0.0.1:8082", AppID: "FakeID", Namespace: "ns1/actorTypeOne", Entities: []string{"actorTypeTwo"}},
+			{Name: "127.0.0.1:8082", AppID: "FakeID", Namespace: "a/b"},
+		} {
+			// act
+			updated, err := s.upsertMember(m)
+
+			// assert
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "'/'")
+			assert.False(t, updated)
+			assert.NotContains(t, s.Members, "127.0.0.1:8082")
+		}
+	})
+
+	t.Run("remove namespaced member", func(t *testing.T) {
+		// act
+		updated, _ := s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+
+		// assert
+		assert.True(t, updated)
+		assert.Equal(t, 1, len(s.hashingTableMap))
+		assert.NotNil(t, s.hashingTableMap["actorTypeOne"])
+	})
+}
+
+func TestResolveActorHost(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	t.Run("resolve actor host", func(t *testing.T) {
+		// act
+		host, appID, ok := s.ResolveActorHost("actorTypeOne", "actor1")
+
+		// assert
+		assert.True(t, ok)
+		expected, err := s.hashingTableMap["actorTypeOne"].Get("actor1")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, host)
+		assert.Equal(t, s.Members[host].AppID, appID)
+	})
+
+	t.Run("unknown actor type", func(t *testing.T) {
+		// act
+		host, appID, ok := s.ResolveActorHost("actorTypeUnknown", "actor1")
+
+		// assert
+		assert.False(t, ok)
+		assert.Empty(t, host)
+		assert.Empty(t, appID)
+	})
+}
+
+func TestUpsertMembers(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+
+	t.Run("batch increases table generation once", func(t *testing.T) {
+		// act
+		updated, err := s.upsertMembers([]*DaprHostMember{
+			{
+				Name:     "127.0.0.1:8080",
+				AppID:    "FakeID",
+				Entities: []string{"actorTypeOne", "actorTypeTwo"},
+			},
+			{
+				Name:     "127.0.0.1:8081",
+				AppID:    "FakeID_2",
+				Entities: []string{"actorTypeOne"},
+			},
+			{
+				Name:     "127.0.0.1:8082",
+				AppID:    "FakeID_3",
+				Entities: []string{},
+			},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, 3, len(s.Members))
+		assert.Equal(t, 2, len(s.hashingTableMap))
+		assert.Equal(t, uint64(1), s.TableGeneration)
+	})
+
+	t.Run("batch without table changes", func(t *testing.T) {
+		// act
+		updated, err := s.upsertMembers([]*DaprHostMember{
+			{
+				Name:     "127.0.0.1:8081",
+				AppID:    "FakeID_2",
+				Entities: []string{"actorTypeOne"},
+			},
+			{
+				Name:     "127.0.0.1:8083",
+				AppID:    "FakeID_4",
+				Entities: []string{},
+			},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, updated)
+		assert.Equal(t, 4, len(s.Members))
+		assert.Equal(t, uint64(1), s.TableGeneration)
+	})
+}
+
+func TestConcurrentReadsAndWrites(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	s := newDaprHostMemberState()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					s.ResolveActorHost("actorTypeOne", "actor1")
+					s.clone()
+				}
+			}
+		}()
+	}
+
+	// act
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("127.0.0.1:%d", 8000+i%10)
+		s.upsertMember(&DaprHostMember{
+			Name:     name,
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+		if i%3 == 0 {
+			s.removeMember(&DaprHostMember{Name: name})
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	// assert
+	assert.LessOrEqual(t, len(s.Members), 10)
+}
+
+func TestDrainMember(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	testMember := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	}
+	s.upsertMember(testMember)
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+
+	t.Run("drain removes host from tables", func(t *testing.T) {
+		gen := s.TableGeneration
+
+		// act
+		updated := s.drainMember(testMember.Name)
+
+		// assert
+		assert.True(t, updated)
+		assert.Equal(t, gen+1, s.TableGeneration)
+		assert.True(t, s.Members[testMember.Name].Draining)
+		assert.Equal(t, []string{"127.0.0.1:8081"}, s.hashingTableMap["actorTypeOne"].Hosts())
+		assert.False(t, s.drainMember(testMember.Name))
+	})
+
+	t.Run("upsert does not add draining host back", func(t *testing.T) {
+		// act
+		updated, err := s.upsertMember(&DaprHostMember{
+			Name:     testMember.Name,
+			AppID:    testMember.AppID,
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, updated)
+		assert.True(t, s.Members[testMember.Name].Draining)
+		assert.Equal(t, []string{"127.0.0.1:8081"}, s.hashingTableMap["actorTypeOne"].Hosts())
+		assert.Equal(t, []string{"127.0.0.1:8081"}, s.hashingTableMap["actorTypeTwo"].Hosts())
+	})
+
+	t.Run("undrain adds host back", func(t *testing.T) {
+		// act
+		updated := s.undrainMember(testMember.Name)
+
+		// assert
+		assert.True(t, updated)
+		assert.False(t, s.Members[testMember.Name].Draining)
+		assert.Equal(t, 2, s.hashingTableMap["actorTypeTwo"].HostCount())
+		assert.False(t, s.undrainMember(testMember.Name))
+	})
+
+	t.Run("remove draining host", func(t *testing.T) {
+		s.drainMember(testMember.Name)
+		gen := s.TableGeneration
+
+		// act
+		updated, _ := s.removeMember(testMember)
+
+		// assert
+		assert.False(t, updated)
+		assert.Equal(t, gen, s.TableGeneration)
+		assert.Equal(t, 1, len(s.Members))
+	})
+}
+
+func TestRemoveMemberWithDelta(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	t.Run("remove actor host", func(t *testing.T) {
+		// act
+		moved, changed := s.removeMemberWithDelta(&DaprHostMember{Name: "127.0.0.1:8080"})
+
+		// assert
+		assert.True(t, changed)
+		assert.Equal(t, map[string][]string{
+			"actorTypeOne": {"127.0.0.1:8081"},
+			"actorTypeTwo": {},
+		}, moved)
+	})
+
+	t.Run("remove unknown host", func(t *testing.T) {
+		// act
+		moved, changed := s.removeMemberWithDelta(&DaprHostMember{Name: "127.0.0.1:9999"})
+
+		// assert
+		assert.False(t, changed)
+		assert.Empty(t, moved)
+	})
+}
+
+func TestUpsertMemberLabels(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	testMember := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+		Labels:   map[string]string{"region": "west"},
+	}
+	s.upsertMember(testMember)
+
+	t.Run("label only change doesn't update tables", func(t *testing.T) {
+		gen := s.TableGeneration
+		oldUpdatedAt := s.Members[testMember.Name].UpdatedAt
+		time.Sleep(10 * time.Millisecond)
+
+		// act
+		updated, err := s.upsertMember(&DaprHostMember{
+			Name:     testMember.Name,
+			AppID:    testMember.AppID,
+			Entities: testMember.Entities,
+			Labels:   map[string]string{"region": "east"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, updated)
+		assert.Equal(t, gen, s.TableGeneration)
+		assert.Equal(t, "east", s.Members[testMember.Name].Labels["region"])
+		assert.True(t, s.Members[testMember.Name].UpdatedAt.After(oldUpdatedAt))
+	})
+
+	t.Run("clone copies labels", func(t *testing.T) {
+		// act
+		newState := s.clone()
+		newState.Members[testMember.Name].Labels["region"] = "north"
+
+		// assert
+		assert.Equal(t, "east", s.Members[testMember.Name].Labels["region"])
+	})
+
+	t.Run("labels survive restore", func(t *testing.T) {
+		// act
+		newState := s.clone()
+		newState.restoreHashingTables()
+
+		// assert
+		assert.Equal(t, "east", newState.Members[testMember.Name].Labels["region"])
+	})
+}
+
+func TestUpsertMemberValidation(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.SetEntityLimits(16, 2)
+
+	var testcases = []struct {
+		name     string
+		entities []string
+	}{
+		{"empty actor type", []string{"actorTypeOne", ""}},
+		{"whitespace actor type", []string{" \t"}},
+		{"too long actor type", []string{"actorTypeWithVeryLongName"}},
+		{"too many actor types", []string{"actorTypeOne", "actorTypeTwo", "actorTypeThree"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// act
+			updated, err := s.upsertMember(&DaprHostMember{
+				Name:     "127.0.0.1:8080",
+				AppID:    "FakeID",
+				Entities: tc.entities,
+			})
+
+			// assert
+			assert.Error(t, err)
+			assert.False(t, updated)
+			assert.Equal(t, 0, len(s.Members))
+			assert.Equal(t, 0, len(s.hashingTableMap))
+		})
+	}
+
+	t.Run("invalid member in batch", func(t *testing.T) {
+		// act
+		updated, err := s.upsertMembers([]*DaprHostMember{
+			{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}},
+			{Name: "127.0.0.1:8081", AppID: "FakeID_2", Entities: []string{""}},
+		})
+
+		// assert
+		assert.Error(t, err)
+		assert.False(t, updated)
+		assert.Equal(t, 0, len(s.Members))
+		assert.Equal(t, uint64(0), s.TableGeneration)
+	})
+}
+
+func TestRestoreHashingTablesFor(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+	untouched := s.hashingTableMap["actorTypeTwo"]
+
+	// corrupt the table of actorTypeOne
+	s.hashingTableMap["actorTypeOne"].Remove("127.0.0.1:8081")
+	s.hashingTableMap["actorTypeThree"] = hashing.NewConsistentHash()
+
+	// act
+	s.restoreHashingTablesFor(map[string]struct{}{
+		"actorTypeOne":   {},
+		"actorTypeThree": {},
+	})
+
+	// assert
+	assert.Equal(t, 2, len(s.hashingTableMap))
+	assert.ElementsMatch(t, []string{"127.0.0.1:8080", "127.0.0.1:8081"}, s.hashingTableMap["actorTypeOne"].Hosts())
+	assert.Same(t, untouched, s.hashingTableMap["actorTypeTwo"])
+}
+
+func TestUpsertMemberPreservesCreatedAt(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	testMember := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	}
+	s.upsertMember(testMember)
+	createdAt := s.Members[testMember.Name].CreatedAt
+	time.Sleep(10 * time.Millisecond)
+
+	// act
+	updated, err := s.upsertMember(&DaprHostMember{
+		Name:     testMember.Name,
+		AppID:    testMember.AppID,
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+
+	// assert
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, createdAt, s.Members[testMember.Name].CreatedAt)
+	assert.True(t, s.Members[testMember.Name].UpdatedAt.After(createdAt))
+}
+
+// setHashFunc sets the hash function used by all hashing tables. Existing
+// tables are not rehashed, so this must be set before any member is added.
+// The hook is kept to tests, since Dapr runtimes rebuild the disseminated
+// tables with the default hash and would route actors elsewhere.
+func (s *DaprHostMemberState) setHashFunc(fn hashing.HashFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.hashFunc = fn
+}
+
+func TestHashFunc(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	s := newDaprHostMemberState()
+	calls := 0
+	s.setHashFunc(func(key []byte) uint64 {
+		calls++
+		return uint64(len(key)) + uint64(calls)
+	})
+
+	// act
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+
+	// assert
+	assert.Equal(t, 20, calls)
+}
+
+func TestTombstone(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.SetTombstoneGracePeriod(time.Minute)
+	testMember := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	}
+	s.upsertMember(testMember)
+	gen := s.TableGeneration
+
+	t.Run("remove keeps tombstoned member in tables", func(t *testing.T) {
+		// act
+		updated, _ := s.removeMember(testMember)
+
+		// assert
+		assert.False(t, updated)
+		assert.Equal(t, gen, s.TableGeneration)
+		assert.False(t, s.Members[testMember.Name].DeletedAt.IsZero())
+		assert.Equal(t, 1, len(s.hashingTableMap))
+	})
+
+	t.Run("upsert within grace period cancels tombstone", func(t *testing.T) {
+		// act
+		updated, err := s.upsertMember(testMember)
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, updated)
+		assert.Equal(t, gen, s.TableGeneration)
+		assert.True(t, s.Members[testMember.Name].DeletedAt.IsZero())
+		assert.Empty(t, s.sweepTombstones())
+	})
+
+	t.Run("sweep removes expired tombstones", func(t *testing.T) {
+		s.removeMember(testMember)
+		assert.Empty(t, s.sweepTombstones())
+		s.Members[testMember.Name].DeletedAt = time.Now().UTC().Add(-2 * time.Minute)
+
+		// act
+		removed := s.sweepTombstones()
+
+		// assert
+		assert.Equal(t, []string{testMember.Name}, removed)
+		assert.Equal(t, gen+1, s.TableGeneration)
+		assert.Equal(t, 0, len(s.Members))
+		assert.Equal(t, 0, len(s.hashingTableMap))
+	})
+}
+
+func TestUpsertMemberWithEntities(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+
+	t.Run("new actor member", func(t *testing.T) {
+		// act
+		changedEntities, changed, err := s.upsertMemberWithEntities(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeTwo", "actorTypeOne"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, changedEntities)
+	})
+
+	t.Run("entities replaced", func(t *testing.T) {
+		// act
+		changedEntities, changed, err := s.upsertMemberWithEntities(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeThree"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeThree", "actorTypeTwo"}, changedEntities)
+	})
+
+	t.Run("idempotent upsert", func(t *testing.T) {
+		// act
+		changedEntities, changed, err := s.upsertMemberWithEntities(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeThree"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Empty(t, changedEntities)
+	})
+}
+
+func TestHashingTableReuse(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+
+	// act
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeTwo"},
+	})
+
+	// assert
+	hosts, sortedSet, loadMap, _ := s.hashingTableMap["actorTypeTwo"].GetInternals()
+	assert.Equal(t, 10, len(sortedSet))
+	assert.Equal(t, 1, len(loadMap))
+	for _, h := range hosts {
+		assert.Equal(t, "127.0.0.1:8081", h)
+	}
+}
+
+func BenchmarkEntityChurn(b *testing.B) {
+	hashing.SetReplicationFactor(100)
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{fmt.Sprintf("ephemeralActorType%d", i)},
+		})
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+	}
+}
+
+func TestRemoveMemberChecked(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:  "127.0.0.1:8081",
+		AppID: "FakeID_2",
+	})
+
+	var testcases = []struct {
+		name         string
+		host         string
+		existed      bool
+		tableChanged bool
+	}{
+		{"actor host", "127.0.0.1:8080", true, true},
+		{"non actor host", "127.0.0.1:8081", true, false},
+		{"unknown host", "127.0.0.1:8082", false, false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// act
+			existed, tableChanged, err := s.removeMemberChecked(&DaprHostMember{Name: tc.host})
+
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.existed, existed)
+			assert.Equal(t, tc.tableChanged, tableChanged)
+		})
+	}
+}
+
+func TestRemoveMembersWhere(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:      "127.0.0.1:8080",
+		AppID:     "FakeID",
+		Namespace: "ns1",
+		Entities:  []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:      "127.0.0.1:8081",
+		AppID:     "FakeID_2",
+		Namespace: "ns1",
+		Entities:  []string{"actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:      "127.0.0.1:8082",
+		AppID:     "FakeID_3",
+		Namespace: "ns2",
+		Entities:  []string{"actorTypeOne"},
+	})
+	generation := s.TableGeneration
+
+	t.Run("remove matching members", func(t *testing.T) {
+		// act
+		removed, changed := s.removeMembersWhere(func(m *DaprHostMember) bool {
+			return m.Namespace == "ns1"
+		})
+
+		// assert
+		assert.True(t, changed)
+		assert.Equal(t, []string{"127.0.0.1:8080", "127.0.0.1:8081"}, removed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+		assert.Equal(t, 1, len(s.Members))
+		assert.NotContains(t, s.hashingTableMap, "ns1/actorTypeOne")
+		assert.NotContains(t, s.hashingTableMap, "ns1/actorTypeTwo")
+		assert.Contains(t, s.hashingTableMap, "ns2/actorTypeOne")
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		// act
+		removed, changed := s.removeMembersWhere(func(m *DaprHostMember) bool {
+			return m.Namespace == "ns3"
+		})
+
+		// assert
+		assert.False(t, changed)
+		assert.Empty(t, removed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+	})
+}
+
+func TestRemoveMembersByAppID(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	for i, appID := range []string{"FakeID", "FakeID_2", "FakeID", "FakeID"} {
+		entities := []string{"actorTypeOne"}
+		if i == 3 {
+			entities = nil
+		}
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    appID,
+			Entities: entities,
+		})
+	}
+	generation := s.TableGeneration
+
+	t.Run("remove all hosts of the app id", func(t *testing.T) {
+		// act
+		removed, changed := s.removeMembersByAppID("FakeID")
+
+		// assert
+		assert.True(t, changed)
+		assert.Equal(t, []string{"127.0.0.1:8080", "127.0.0.1:8082", "127.0.0.1:8083"}, removed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+		assert.Equal(t, []string{"127.0.0.1:8081"}, sortedMemberNames(s.Members))
+		assert.Equal(t, 1, s.hashingTableMap["actorTypeOne"].HostCount())
+	})
+
+	t.Run("unknown app id", func(t *testing.T) {
+		// act
+		removed, changed := s.removeMembersByAppID("FakeID_3")
+
+		// assert
+		assert.False(t, changed)
+		assert.Empty(t, removed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+	})
+}
+
+func TestUpsertMemberVersion(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	host := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	}
+
+	// act
+	s.upsertMember(host)
+
+	// assert
+	assert.Equal(t, uint64(1), s.Members[host.Name].Version)
+
+	t.Run("no-op upsert keeps version", func(t *testing.T) {
+		s.upsertMember(host)
+		assert.Equal(t, uint64(1), s.Members[host.Name].Version)
+	})
+
+	t.Run("entity change increases version", func(t *testing.T) {
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+		assert.Equal(t, uint64(2), s.Members[host.Name].Version)
+	})
+
+	t.Run("label change increases version", func(t *testing.T) {
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+			Labels:   map[string]string{"zone": "a"},
+		})
+		assert.Equal(t, uint64(3), s.Members[host.Name].Version)
+		assert.Equal(t, uint64(3), s.Members[host.Name].clone().Version)
+	})
+}
+
+func TestPlanRemoval(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+	generation := s.TableGeneration
+
+	t.Run("plan successors", func(t *testing.T) {
+		// act
+		plan := s.planRemoval("127.0.0.1:8080")
+
+		// assert
+		assert.Equal(t, 2, len(plan))
+		assert.Equal(t, 10, len(plan["actorTypeOne"]))
+		for _, host := range plan["actorTypeOne"] {
+			assert.Equal(t, "127.0.0.1:8081", host)
+		}
+		assert.Empty(t, plan["actorTypeTwo"])
+		assert.Contains(t, plan, "actorTypeTwo")
+		assert.Equal(t, generation, s.TableGeneration)
+		assert.Equal(t, 2, s.hashingTableMap["actorTypeOne"].HostCount())
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		assert.Empty(t, s.planRemoval("127.0.0.1:9999"))
+	})
+}
+
+func TestUpsertMemberAppIDOnly(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newDaprHostMemberState()
+	s.SetClock(func() time.Time { return now })
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	generation := s.TableGeneration
+	namespaceGeneration := s.NamespaceGeneration("")
+	hostSetGeneration := s.EntityHostSetGeneration("actorTypeOne")
+	hosts, _, _, _ := s.hashingTableMap["actorTypeOne"].GetInternals()
+	vnodes := len(hosts)
+	now = now.Add(time.Minute)
+
+	// act
+	updated, err := s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+
+	// assert
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, generation+1, s.TableGeneration)
+	assert.Equal(t, "FakeID_2", s.Members["127.0.0.1:8080"].AppID)
+	assert.Equal(t, uint64(2), s.Members["127.0.0.1:8080"].Version)
+	for _, e := range []string{"actorTypeOne", "actorTypeTwo"} {
+		host, err := s.hashingTableMap[e].GetHost("actor1")
+		assert.NoError(t, err)
+		assert.Equal(t, "FakeID_2", host.AppID)
+	}
+	hosts, _, _, _ = s.hashingTableMap["actorTypeOne"].GetInternals()
+	assert.Equal(t, vnodes, len(hosts))
+
+	d, ok := s.TableDelta(generation)
+	assert.True(t, ok)
+	expected := map[string][]string{
+		"actorTypeOne": {"127.0.0.1:8080"},
+		"actorTypeTwo": {"127.0.0.1:8080"},
+	}
+	assert.Equal(t, expected, d.Added)
+	assert.Equal(t, expected, d.Removed)
+	assert.Equal(t, namespaceGeneration+1, s.NamespaceGeneration(""))
+	changedAt, ok := s.EntityLastChanged("actorTypeOne")
+	assert.True(t, ok)
+	assert.Equal(t, now, changedAt)
+	assert.Equal(t, hostSetGeneration, s.EntityHostSetGeneration("actorTypeOne"))
+}
+
+func TestCompact(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:      "127.0.0.1:8080",
+		AppID:     "FakeID",
+		Namespace: "ns1",
+		Entities:  []string{"actorTypeOne"},
+	})
+	s.hashingTableMap["actorTypeTwo"] = hashing.NewConsistentHash()
+	s.hashingTableMap["ns2/actorTypeOne"] = nil
+	generation := s.TableGeneration
+
+	// act
+	reclaimed := s.Compact()
+
+	// assert
+	assert.Equal(t, 2, reclaimed)
+	assert.Equal(t, 1, len(s.hashingTableMap))
+	assert.Contains(t, s.hashingTableMap, "ns1/actorTypeOne")
+	assert.Equal(t, generation, s.TableGeneration)
+	assert.Equal(t, 0, s.Compact())
+}
+
+func TestSetReplicationFactor(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.SetReplicationFactor(3)
+
+	// act
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	// assert
+	ring, ok := s.EntityRing("actorTypeOne")
+	assert.True(t, ok)
+	assert.Equal(t, 3, len(ring))
+}
+
+func TestResolveActorReplicas(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	for i := 0; i < 3; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    fmt.Sprintf("FakeID_%d", i),
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+
+	t.Run("owner comes first", func(t *testing.T) {
+		// act
+		hosts := s.ResolveActorReplicas("actorTypeOne", "actor1", 2)
+
+		// assert
+		host, _, _ := s.ResolveActorHost("actorTypeOne", "actor1")
+		assert.Equal(t, 2, len(hosts))
+		assert.Equal(t, host, hosts[0])
+		assert.NotEqual(t, hosts[0], hosts[1])
+	})
+
+	t.Run("ring smaller than n", func(t *testing.T) {
+		assert.Equal(t, 3, len(s.ResolveActorReplicas("actorTypeOne", "actor1", 5)))
+	})
+
+	t.Run("unknown actor type", func(t *testing.T) {
+		assert.Empty(t, s.ResolveActorReplicas("actorTypeUnknown", "actor1", 2))
+	})
+}
+
+func TestStrictAppID(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	changed := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	}
+
+	t.Run("strict mode rejects app id change", func(t *testing.T) {
+		// act
+		s.SetStrictAppID(true)
+		updated, err := s.upsertMember(changed)
+
+		// assert
+		assert.False(t, updated)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "FakeID")
+		assert.Contains(t, err.Error(), "FakeID_2")
+		assert.Equal(t, "FakeID", s.Members["127.0.0.1:8080"].AppID)
+	})
+
+	t.Run("lenient mode accepts app id change", func(t *testing.T) {
+		// act
+		s.SetStrictAppID(false)
+		_, err := s.upsertMember(changed)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "FakeID_2", s.Members["127.0.0.1:8080"].AppID)
+	})
+}
+
+func TestNameCollisionWindow(t *testing.T) {
+	// arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newDaprHostMemberState()
+	s.SetClock(func() time.Time { return now })
+	s.SetNameCollisionWindow(time.Minute)
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	other := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	}
+
+	t.Run("recent host with another app id is a collision", func(t *testing.T) {
+		now = now.Add(30 * time.Second)
+
+		// act
+		updated, err := s.upsertMember(other)
+
+		// assert
+		assert.False(t, updated)
+		assert.Equal(t, ErrNameCollision, errors.Cause(err))
+		assert.Contains(t, err.Error(), "FakeID")
+		assert.Contains(t, err.Error(), "FakeID_2")
+		assert.Equal(t, "FakeID", s.Members["127.0.0.1:8080"].AppID)
+	})
+
+	t.Run("same app id is an update", func(t *testing.T) {
+		// act
+		_, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("stale host is overwritten", func(t *testing.T) {
+		now = now.Add(time.Minute)
+
+		// act
+		_, err := s.upsertMember(other)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "FakeID_2", s.Members["127.0.0.1:8080"].AppID)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		s.SetNameCollisionWindow(0)
+
+		// act
+		_, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, "FakeID", s.Members["127.0.0.1:8080"].AppID)
+	})
+}
+
+func TestRestoreHashingTablesCtx(t *testing.T) {
+	newTestState := func() *DaprHostMemberState {
+		s := newDaprHostMemberState()
+		for i := 0; i < 2*restoreCheckInterval; i++ {
+			s.upsertMember(&DaprHostMember{
+				Name:     fmt.Sprintf("127.0.0.1:%d", 8000+i),
+				AppID:    "FakeID",
+				Entities: []string{"actorTypeOne"},
+			})
+		}
+		return s.clone()
+	}
+
+	t.Run("restore all tables", func(t *testing.T) {
+		// arrange
+		s := newTestState()
+
+		// act
+		err := s.restoreHashingTablesCtx(context.Background())
+
+		// assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2*restoreCheckInterval, s.hashingTableMap["actorTypeOne"].HostCount())
+	})
+
+	t.Run("cancelled restore", func(t *testing.T) {
+		// arrange
+		s := newTestState()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// act
+		err := s.restoreHashingTablesCtx(ctx)
+
+		// assert
+		assert.Equal(t, context.Canceled, err)
+		assert.NotNil(t, s.hashingTableMap)
+		assert.Empty(t, s.hashingTableMap)
+	})
+
+	t.Run("mutations after a cancelled restore", func(t *testing.T) {
+		// arrange
+		s := newTestState()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.Equal(t, context.Canceled, s.restoreHashingTablesCtx(ctx))
+
+		// act
+		assert.NotPanics(t, func() {
+			s.mergeMemberEntities("127.0.0.1:8000", []string{"actorTypeTwo"})
+			s.setMemberEntities("127.0.0.1:8001", []string{"actorTypeTwo"})
+		})
+		assert.NoError(t, s.restoreHashingTablesCtx(context.Background()))
+		merged := s.mergeMemberEntities("127.0.0.1:8000", []string{"actorTypeTwo"})
+		_, _, set := s.setMemberEntities("127.0.0.1:8001", []string{"actorTypeTwo"})
+
+		// assert
+		assert.True(t, merged)
+		assert.True(t, set)
+		assert.Equal(t, 2, s.hashingTableMap["actorTypeTwo"].HostCount())
+		assert.Equal(t, 2*restoreCheckInterval-1, s.hashingTableMap["actorTypeOne"].HostCount())
+	})
+
+	t.Run("concurrent mutations are rejected", func(t *testing.T) {
+		// arrange
+		hashing.SetReplicationFactor(10)
+		defer hashing.SetReplicationFactor(0)
+		s := newTestState()
+		s.restoreHashingTables()
+		ctx := &pausingContext{
+			Context: context.Background(),
+			paused:  make(chan struct{}),
+			resume:  make(chan struct{}),
+		}
+		done := make(chan error)
+		go func() {
+			done <- s.restoreHashingTablesCtx(ctx)
+		}()
+		<-ctx.paused
+
+		// act
+		_, upsertErr := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:9999",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+		_, removeErr := s.removeMember(&DaprHostMember{Name: "127.0.0.1:8000"})
+		replaced := s.ReplaceMembers([]*DaprHostMember{})
+		host, _, ok := s.ResolveActorHost("actorTypeOne", "actor1")
+		close(ctx.resume)
+
+		// assert
+		assert.Equal(t, ErrRestoring, upsertErr)
+		assert.Equal(t, ErrRestoring, removeErr)
+		assert.False(t, replaced)
+		assert.True(t, ok)
+		assert.NotEmpty(t, host)
+		assert.NoError(t, <-done)
+		assert.Len(t, s.Members, 2*restoreCheckInterval)
+		assert.Equal(t, 2*restoreCheckInterval, s.hashingTableMap["actorTypeOne"].HostCount())
+	})
+
+	t.Run("mutations are rejected until the restore completes", func(t *testing.T) {
+		// arrange
+		s := newTestState()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_ = s.restoreHashingTablesCtx(ctx)
+		host := &DaprHostMember{
+			Name:     "127.0.0.1:9999",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		}
+
+		// act
+		_, upsertErr := s.upsertMember(host)
+		_, removeErr := s.removeMember(&DaprHostMember{Name: "127.0.0.1:8000"})
+		_, batchErr := s.upsertMembers([]*DaprHostMember{host})
+		wouldChange, _, previewErr := s.previewUpsert(host)
+
+		// assert
+		assert.Equal(t, ErrRestoring, upsertErr)
+		assert.Equal(t, ErrRestoring, removeErr)
+		assert.Equal(t, ErrRestoring, batchErr)
+		assert.Equal(t, ErrRestoring, previewErr)
+		assert.False(t, wouldChange)
+		assert.False(t, s.heartbeat("127.0.0.1:8000"))
+		assert.False(t, s.mergeMemberEntities("127.0.0.1:8000", []string{"actorTypeTwo"}))
+		_, _, changed := s.setMemberEntities("127.0.0.1:8000", []string{"actorTypeTwo"})
+		assert.False(t, changed)
+		assert.False(t, s.drainMember("127.0.0.1:8000"))
+		assert.Equal(t, ErrRestoring, s.AddPin("actorTypeOne", "singleton", "127.0.0.1:8000"))
+		assert.Equal(t, ErrRestoring, s.AddAlias("actorTypeOld", "actorTypeOne"))
+		assert.Len(t, s.Members, 2*restoreCheckInterval)
+		assert.Equal(t, []string{"actorTypeOne"}, s.Members["127.0.0.1:8000"].Entities)
+
+		// act
+		assert.NoError(t, s.restoreHashingTablesCtx(context.Background()))
+		updated, err := s.upsertMember(host)
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, 2*restoreCheckInterval+1, s.hashingTableMap["actorTypeOne"].HostCount())
+	})
+}
+
+// pausingContext blocks the first check of Err until resume is closed, so
+// that tests can act in the middle of a rebuild.
+type pausingContext struct {
+	context.Context
+	once   sync.Once
+	paused chan struct{}
+	resume chan struct{}
+}
+
+func (c *pausingContext) Err() error {
+	c.once.Do(func() {
+		close(c.paused)
+		<-c.resume
+	})
+	return c.Context.Err()
+}
+
+func TestUpsertMemberEntityWeights(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	host := &DaprHostMember{
+		Name:          "127.0.0.1:8080",
+		AppID:         "FakeID",
+		Entities:      []string{"actorTypeOne", "actorTypeTwo", "actorTypeThree"},
+		Weight:        2,
+		EntityWeights: map[string]int{"actorTypeOne": 3, "actorTypeTwo": 0},
+	}
+
+	// act
+	s.upsertMember(host)
+
+	// assert
+	vnodes := func(entity string) int {
+		ring, _ := s.EntityRing(entity)
+		return len(ring)
+	}
+	assert.Equal(t, 30, vnodes("actorTypeOne"))
+	assert.Equal(t, 10, vnodes("actorTypeTwo"))
+	assert.Equal(t, 20, vnodes("actorTypeThree"))
+
+	t.Run("clone copies entity weights", func(t *testing.T) {
+		c := s.Members[host.Name].clone()
+		c.EntityWeights["actorTypeOne"] = 5
+		assert.Equal(t, 3, s.Members[host.Name].EntityWeights["actorTypeOne"])
+	})
+
+	t.Run("entity weight change updates the table", func(t *testing.T) {
+		// act
+		updated, err := s.upsertMember(&DaprHostMember{
+			Name:          "127.0.0.1:8080",
+			AppID:         "FakeID",
+			Entities:      []string{"actorTypeOne", "actorTypeTwo", "actorTypeThree"},
+			Weight:        2,
+			EntityWeights: map[string]int{"actorTypeOne": 1},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, 10, vnodes("actorTypeOne"))
+		assert.Equal(t, 20, vnodes("actorTypeTwo"))
+	})
+}
+
+func TestReplaceMembers(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.Index = 10
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeTwo"},
+	})
+	o := &fakeObserver{}
+	s.RegisterObserver(o)
+	generation := s.TableGeneration
+
+	t.Run("replace with the same topology", func(t *testing.T) {
+		// act
+		changed := s.ReplaceMembers([]*DaprHostMember{
+			{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}},
+			{Name: "127.0.0.1:8081", AppID: "FakeID_2", Entities: []string{"actorTypeTwo"}},
+			{Name: "127.0.0.1:8082", AppID: "FakeID_3"},
+		})
+
+		// assert
+		assert.False(t, changed)
+		assert.Equal(t, generation, s.TableGeneration)
+		assert.Equal(t, uint64(10), s.Index)
+		assert.Equal(t, 3, len(s.Members))
+		assert.Contains(t, o.events, "added:127.0.0.1:8082")
+		d, ok := s.TableDelta(generation)
+		assert.True(t, ok)
+		assert.Empty(t, d.Added)
+	})
+
+	t.Run("replace with a different topology", func(t *testing.T) {
+		// act
+		changed := s.ReplaceMembers([]*DaprHostMember{
+			{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}},
+			{Name: "127.0.0.1:8083", AppID: "FakeID_4", Entities: []string{"actorTypeTwo"}, Draining: true},
+		})
+
+		// assert
+		assert.True(t, changed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+		assert.Equal(t, uint64(10), s.Index)
+		assert.Equal(t, 2, len(s.Members))
+		assert.Contains(t, s.hashingTableMap, "actorTypeOne")
+		assert.NotContains(t, s.hashingTableMap, "actorTypeTwo")
+		assert.Contains(t, o.events, "removed:127.0.0.1:8081")
+		assert.Contains(t, o.events, "removed:127.0.0.1:8082")
+		assert.Empty(t, s.Verify())
+	})
+
+	t.Run("members are normalized like upserts", func(t *testing.T) {
+		// arrange
+		r := newDaprHostMemberState()
+		r.SetMaxReplicas(map[string]int{"actorTypeOne": 1})
+		r.ReplaceMembers([]*DaprHostMember{
+			{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeTwo", "actorTypeOne", "actorTypeOne"}},
+			{Name: "127.0.0.1:8081", AppID: "FakeID_2", Entities: []string{"actorTypeOne"}},
+		})
+		generation := r.TableGeneration
+
+		// act
+		changed, err := r.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, generation, r.TableGeneration)
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, r.Members["127.0.0.1:8080"].Entities)
+		assert.Equal(t, []string{"actorTypeOne"}, r.Members["127.0.0.1:8081"].SkippedEntities)
+		assert.Equal(t, []string{"127.0.0.1:8080"}, r.hashingTableMap["actorTypeOne"].Hosts())
+		assert.Len(t, r.entityNames, 2)
+		assert.Empty(t, r.Verify())
+	})
+
+	t.Run("hashing table with an unknown host", func(t *testing.T) {
+		// arrange
+		s.hashingTableMap["actorTypeOne"].Add("127.0.0.1:9999", "FakeID_5", 0)
+		generation := s.TableGeneration
+
+		// act
+		var changed bool
+		assert.NotPanics(t, func() {
+			changed = s.ReplaceMembers([]*DaprHostMember{
+				{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}},
+			})
+		})
+
+		// assert
+		assert.True(t, changed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+		assert.False(t, s.hashingTableMap["actorTypeOne"].Contains("127.0.0.1:9999"))
+		assert.Empty(t, s.Verify())
+	})
+}
+
+func TestSetAllowedEntities(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.SetAllowedEntities(map[string]struct{}{"actorTypeOne": {}})
+
+	t.Run("allowed actor types", func(t *testing.T) {
+		// act
+		updated, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, updated)
+	})
+
+	t.Run("actor type not allowed", func(t *testing.T) {
+		// act
+		updated, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+
+		// assert
+		assert.Error(t, err)
+		assert.False(t, updated)
+		assert.NotContains(t, s.Members, "127.0.0.1:8081")
+	})
+
+	t.Run("nil allows any actor type", func(t *testing.T) {
+		// act
+		s.SetAllowedEntities(nil)
+		_, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeTwo"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+	})
+}
+
+func TestResolveActorCandidates(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	newTestState := func(order []int) *DaprHostMemberState {
+		s := newDaprHostMemberState()
+		for _, i := range order {
+			s.upsertMember(&DaprHostMember{
+				Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+				AppID:    fmt.Sprintf("FakeID_%d", i),
+				Entities: []string{"actorTypeOne"},
+			})
+		}
+		return s
+	}
+	s := newTestState([]int{0, 1, 2, 3})
+
+	t.Run("all hosts starting with the owner", func(t *testing.T) {
+		// act
+		hosts := s.ResolveActorCandidates("actorTypeOne", "actor1")
+
+		// assert
+		owner, _, _ := s.ResolveActorHost("actorTypeOne", "actor1")
+		assert.Equal(t, 4, len(hosts))
+		assert.Equal(t, owner, hosts[0])
+		assert.ElementsMatch(t, []string{"127.0.0.1:8080", "127.0.0.1:8081", "127.0.0.1:8082", "127.0.0.1:8083"}, hosts)
+	})
+
+	t.Run("stable across identical tables", func(t *testing.T) {
+		other := newTestState([]int{3, 1, 0, 2})
+		for i := 0; i < 20; i++ {
+			actorID := fmt.Sprintf("actor%d", i)
+			assert.Equal(t,
+				s.ResolveActorCandidates("actorTypeOne", actorID),
+				other.ResolveActorCandidates("actorTypeOne", actorID))
+		}
+	})
+
+	t.Run("unknown actor type", func(t *testing.T) {
+		assert.Empty(t, s.ResolveActorCandidates("actorTypeUnknown", "actor1"))
+	})
+}
+
+func TestPreviewUpsert(t *testing.T) {
+	newTestState := func() *DaprHostMemberState {
+		s := newDaprHostMemberState()
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeTwo"},
+		})
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8083",
+			AppID:    "FakeID_4",
+			Entities: []string{"actorTypeFull"},
+		})
+		s.drainMember("127.0.0.1:8081")
+		s.SetEntityLimits(20, 0)
+		s.SetMaxReplicas(map[string]int{"actorTypeFull": 1})
+		return s
+	}
+
+	var testcases = []struct {
+		name string
+		host *DaprHostMember
+	}{
+		{"idempotent heartbeat", &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}}},
+		{"label change", &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}, Labels: map[string]string{"zone": "a"}}},
+		{"app id change", &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID_3", Entities: []string{"actorTypeOne"}}},
+		{"entity change", &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeThree"}}},
+		{"weight change", &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}, Weight: 2}},
+		{"host stops serving", &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID"}},
+		{"draining host change", &DaprHostMember{Name: "127.0.0.1:8081", AppID: "FakeID_2", Entities: []string{"actorTypeThree"}}},
+		{"new actor host", &DaprHostMember{Name: "127.0.0.1:8082", AppID: "FakeID_3", Entities: []string{"actorTypeOne"}}},
+		{"new non actor host", &DaprHostMember{Name: "127.0.0.1:8082", AppID: "FakeID_3"}},
+		{"invalid host", &DaprHostMember{Name: "127.0.0.1:8082", AppID: "FakeID_3", Entities: []string{"actorTypeWithAVeryLongName"}}},
+		{"full table", &DaprHostMember{Name: "127.0.0.1:8082", AppID: "FakeID_3", Entities: []string{"actorTypeFull", "actorTypeOne"}}},
+		{"host in full table changes", &DaprHostMember{Name: "127.0.0.1:8083", AppID: "FakeID_4", Entities: []string{"actorTypeFull"}, Weight: 2}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// arrange
+			s := newTestState()
+			before := s.cloneWithTables()
+
+			// act
+			wouldChange, affected, previewErr := s.previewUpsert(tc.host)
+
+			// assert
+			assert.True(t, s.Equal(before))
+
+			changedEntities, changed, err := s.upsertMemberWithEntities(tc.host)
+			assert.Equal(t, changed, wouldChange)
+			assert.Equal(t, changedEntities, affected)
+			if err != nil {
+				assert.EqualError(t, previewErr, err.Error())
+			} else {
+				assert.NoError(t, previewErr)
+			}
+		})
+	}
+}
+
+func TestSetMaxReplicas(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.SetMaxReplicas(map[string]int{"actorTypeOne": 2})
+	for i := 0; i < 2; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    fmt.Sprintf("FakeID_%d", i),
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+	host := &DaprHostMember{
+		Name:     "127.0.0.1:8082",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	}
+
+	t.Run("host skips the full table", func(t *testing.T) {
+		// act
+		skipped, changed, err := s.upsertMemberWithSkipped(host)
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, []string{"actorTypeOne"}, skipped)
+		assert.Equal(t, 2, s.hashingTableMap["actorTypeOne"].HostCount())
+		assert.Equal(t, []string{"actorTypeTwo"}, s.HostEntities(host.Name))
+		assert.Empty(t, s.Verify())
+	})
+
+	t.Run("heartbeat keeps the skip", func(t *testing.T) {
+		// act
+		skipped, changed, err := s.upsertMemberWithSkipped(host)
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, []string{"actorTypeOne"}, skipped)
+	})
+
+	t.Run("restore keeps the skip", func(t *testing.T) {
+		// act
+		c := s.clone()
+		c.restoreHashingTables()
+
+		// assert
+		assert.True(t, s.Equal(c))
+	})
+
+	t.Run("changed host rejoins a table with room", func(t *testing.T) {
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+		host.Weight = 2
+		skipped, changed, err := s.upsertMemberWithSkipped(host)
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		assert.Empty(t, skipped)
+		assert.Equal(t, 2, s.hashingTableMap["actorTypeOne"].HostCount())
+	})
+
+	t.Run("host in a full table keeps its place", func(t *testing.T) {
+		// act
+		host.Weight = 3
+		skipped, _, err := s.upsertMemberWithSkipped(host)
+
+		// assert
+		assert.NoError(t, err)
+		assert.Empty(t, skipped)
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, s.HostEntities(host.Name))
+	})
+}
+
+func TestSetBoundedLoads(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	newTestState := func(factor float64) *DaprHostMemberState {
+		s := newDaprHostMemberState()
+		assert.NoError(t, s.SetBoundedLoads(factor))
+		for i := 0; i < 3; i++ {
+			s.upsertMember(&DaprHostMember{
+				Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+				AppID:    fmt.Sprintf("FakeID_%d", i),
+				Entities: []string{"actorTypeOne"},
+			})
+		}
+		return s
+	}
+
+	t.Run("overloaded owner is skipped", func(t *testing.T) {
+		s := newTestState(1.25)
+		owner, _, _ := s.ResolveActorHost("actorTypeOne", "actor1")
+
+		// act
+		assert.True(t, s.SetHostLoad("actorTypeOne", owner, 100))
+		host, appID, ok := s.ResolveActorHost("actorTypeOne", "actor1")
+
+		// assert
+		assert.True(t, ok)
+		assert.NotEqual(t, owner, host)
+		assert.Equal(t, s.Members[host].AppID, appID)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		s := newTestState(0)
+		owner, _, _ := s.ResolveActorHost("actorTypeOne", "actor1")
+
+		// act
+		s.SetHostLoad("actorTypeOne", owner, 100)
+		host, _, _ := s.ResolveActorHost("actorTypeOne", "actor1")
+
+		// assert
+		assert.Equal(t, owner, host)
+	})
+
+	t.Run("unknown host", func(t *testing.T) {
+		s := newTestState(1.25)
+		assert.False(t, s.SetHostLoad("actorTypeOne", "127.0.0.1:9999", 1))
+		assert.False(t, s.SetHostLoad("actorTypeTwo", "127.0.0.1:8080", 1))
+	})
+
+	t.Run("equal loads at the minimum factor", func(t *testing.T) {
+		s := newTestState(1.01)
+		for i := 0; i < 3; i++ {
+			s.SetHostLoad("actorTypeOne", fmt.Sprintf("127.0.0.1:808%d", i), 1)
+		}
+
+		// act
+		host, _, ok := s.ResolveActorHost("actorTypeOne", "actor1")
+
+		// assert
+		assert.True(t, ok)
+		assert.Contains(t, s.Members, host)
+	})
+
+	t.Run("factor not greater than one", func(t *testing.T) {
+		for _, factor := range []float64{1, 0.5, 0.99, -1} {
+			s := newDaprHostMemberState()
+
+			// act
+			err := s.SetBoundedLoads(factor)
+
+			// assert
+			assert.Error(t, err)
+			assert.Equal(t, float64(0), s.loadFactor)
+		}
+	})
+}
+
+func TestRenameMember(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:  "127.0.0.1:8081",
+		AppID: "FakeID_2",
+	})
+	createdAt := s.Members["127.0.0.1:8080"].CreatedAt
+	generation := s.TableGeneration
+
+	t.Run("rename actor host", func(t *testing.T) {
+		// act
+		changed := s.renameMember("127.0.0.1:8080", "127.0.0.1:9090")
+
+		// assert
+		assert.True(t, changed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+		assert.NotContains(t, s.Members, "127.0.0.1:8080")
+		m := s.Members["127.0.0.1:9090"]
+		assert.Equal(t, "127.0.0.1:9090", m.Name)
+		assert.Equal(t, createdAt, m.CreatedAt)
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, s.HostEntities("127.0.0.1:9090"))
+		assert.Empty(t, s.HostEntities("127.0.0.1:8080"))
+		assert.Empty(t, s.Verify())
+	})
+
+	t.Run("rename non actor host", func(t *testing.T) {
+		// act
+		changed := s.renameMember("127.0.0.1:8081", "127.0.0.1:9091")
+
+		// assert
+		assert.True(t, changed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+		assert.Contains(t, s.Members, "127.0.0.1:9091")
+	})
+
+	t.Run("unknown host or name in use", func(t *testing.T) {
+		assert.False(t, s.renameMember("127.0.0.1:8080", "127.0.0.1:9092"))
+		assert.False(t, s.renameMember("127.0.0.1:9090", "127.0.0.1:9091"))
+		assert.False(t, s.renameMember("127.0.0.1:9090", "127.0.0.1:9090"))
+	})
+}
+
+func TestUpsertMemberDuplicatedEntities(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	duplicated := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeTwo", "actorTypeOne", "actorTypeTwo", "actorTypeOne"},
+	}
+
+	t.Run("duplicated entities are removed", func(t *testing.T) {
+		// act
+		changed, err := s.upsertMember(duplicated)
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeTwo"}, s.Members["127.0.0.1:8080"].Entities)
+		assert.Len(t, s.hashingTableMap, 2)
+		assert.Equal(t, 10, s.hashingTableMap["actorTypeOne"].VirtualNodeCount())
+		assert.Equal(t, 4, len(duplicated.Entities))
+	})
+
+	t.Run("duplicates are the same as unique entities", func(t *testing.T) {
+		generation := s.TableGeneration
+
+		// act
+		wouldChange, _, _ := s.previewUpsert(duplicated)
+		changed, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeTwo", "actorTypeTwo", "actorTypeOne"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, wouldChange)
+		assert.False(t, changed)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+
+	t.Run("batch upsert", func(t *testing.T) {
+		generation := s.TableGeneration
+
+		// act
+		changed, err := s.upsertMembers([]*DaprHostMember{duplicated})
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+}
+
+func TestPins(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	for i := 0; i < 3; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    fmt.Sprintf("FakeID_%d", i),
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:9090",
+		AppID:    "Coordinator",
+		Entities: []string{"actorTypeOne"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:  "127.0.0.1:9091",
+		AppID: "NonActorHost",
+	})
+	owner, _, _ := s.ResolveActorHost("actorTypeOne", "singleton")
+	ringOwner := func(actorID string) string {
+		h, err := s.hashingTableMap["actorTypeOne"].GetHost(actorID)
+		assert.NoError(t, err)
+		return h.Name
+	}
+	generation := s.TableGeneration
+
+	t.Run("pinned actor resolves to the pinned host", func(t *testing.T) {
+		// act
+		assert.NoError(t, s.AddPin("actorTypeOne", "singleton", "127.0.0.1:9090"))
+		host, appID, ok := s.ResolveActorHost("actorTypeOne", "singleton")
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, "127.0.0.1:9090", host)
+		assert.Equal(t, "Coordinator", appID)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+
+	t.Run("pins survive clone", func(t *testing.T) {
+		// act
+		cloned := s.clone()
+
+		// assert
+		assert.Equal(t, s.Pins, cloned.Pins)
+	})
+
+	t.Run("pin to unknown host falls through to the ring", func(t *testing.T) {
+		// act
+		assert.NoError(t, s.AddPin("actorTypeOne", "singleton", "127.0.0.1:9999"))
+		host, _, ok := s.ResolveActorHost("actorTypeOne", "singleton")
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, owner, host)
+	})
+
+	t.Run("remove pin", func(t *testing.T) {
+		// act
+		removed := s.RemovePin("actorTypeOne", "singleton")
+		removedAgain := s.RemovePin("actorTypeOne", "singleton")
+
+		// assert
+		assert.True(t, removed)
+		assert.False(t, removedAgain)
+		assert.Empty(t, s.Pins)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+
+	t.Run("pin to a host out of the hashing table falls through to the ring", func(t *testing.T) {
+		// act
+		assert.NoError(t, s.AddPin("actorTypeOne", "singleton", "127.0.0.1:9091"))
+		host, _, ok := s.ResolveActorHost("actorTypeOne", "singleton")
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, owner, host)
+		assert.True(t, s.RemovePin("actorTypeOne", "singleton"))
+	})
+
+	t.Run("pin to a drained host falls through to the ring", func(t *testing.T) {
+		// arrange
+		assert.NoError(t, s.AddPin("actorTypeOne", "singleton", "127.0.0.1:8081"))
+		assert.True(t, s.drainMember("127.0.0.1:8081"))
+
+		// act
+		host, _, ok := s.ResolveActorHost("actorTypeOne", "singleton")
+
+		// assert
+		assert.True(t, ok)
+		assert.NotEqual(t, "127.0.0.1:8081", host)
+		assert.Equal(t, ringOwner("singleton"), host)
+		assert.True(t, s.RemovePin("actorTypeOne", "singleton"))
+	})
+
+	t.Run("pin to a host which no longer serves the entity falls through to the ring", func(t *testing.T) {
+		// arrange
+		assert.NoError(t, s.AddPin("actorTypeOne", "singleton", "127.0.0.1:8082"))
+		_, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8082",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeTwo"},
+		})
+		assert.NoError(t, err)
+
+		// act
+		host, _, ok := s.ResolveActorHost("actorTypeOne", "singleton")
+
+		// assert
+		assert.True(t, ok)
+		assert.NotEqual(t, "127.0.0.1:8082", host)
+		assert.Equal(t, ringOwner("singleton"), host)
+	})
+}
+
+func TestDecayMember(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:          "127.0.0.1:8080",
+		AppID:         "FakeID",
+		Entities:      []string{"actorTypeOne", "actorTypeTwo"},
+		Weight:        6,
+		EntityWeights: map[string]int{"actorTypeTwo": 3},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	t.Run("unknown member", func(t *testing.T) {
+		removed, changed := s.decayMember("127.0.0.1:9999", 3)
+		assert.False(t, removed)
+		assert.False(t, changed)
+	})
+
+	t.Run("weights decay over the steps", func(t *testing.T) {
+		testcases := []struct {
+			steps           int
+			weight          int
+			entityWeight    int
+			vnodesOfTypeOne int
+		}{
+			{steps: 3, weight: 4, entityWeight: 2, vnodesOfTypeOne: 50},
+			{steps: 2, weight: 2, entityWeight: 1, vnodesOfTypeOne: 30},
+		}
+		for _, tc := range testcases {
+			generation := s.TableGeneration
+
+			// act
+			removed, changed := s.decayMember("127.0.0.1:8080", tc.steps)
+
+			// assert
+			assert.False(t, removed)
+			assert.True(t, changed)
+			assert.Equal(t, generation+1, s.TableGeneration)
+			m := s.Members["127.0.0.1:8080"]
+			assert.Equal(t, tc.weight, m.Weight)
+			assert.Equal(t, tc.entityWeight, m.EntityWeights["actorTypeTwo"])
+			assert.Equal(t, tc.vnodesOfTypeOne, s.hashingTableMap["actorTypeOne"].VirtualNodeCount())
+		}
+	})
+
+	t.Run("last step removes the member", func(t *testing.T) {
+		generation := s.TableGeneration
+
+		// act
+		removed, changed := s.decayMember("127.0.0.1:8080", 1)
+
+		// assert
+		assert.True(t, removed)
+		assert.True(t, changed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+		assert.NotContains(t, s.Members, "127.0.0.1:8080")
+		assert.NotContains(t, s.hashingTableMap, "actorTypeTwo")
+	})
+
+	t.Run("minimum weight doesn't change tables", func(t *testing.T) {
+		generation := s.TableGeneration
+
+		// act
+		removed, changed := s.decayMember("127.0.0.1:8081", 2)
+
+		// assert
+		assert.False(t, removed)
+		assert.False(t, changed)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+}
+
+func TestResolveBatch(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	for i := 0; i < 3; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    fmt.Sprintf("FakeID_%d", i),
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+	assert.NoError(t, s.AddPin("actorTypeOne", "pinned", "127.0.0.1:8082"))
+	actorIDs := []string{"actor1", "actor2", "actor3", "pinned"}
+
+	t.Run("resolves as ResolveActorHost", func(t *testing.T) {
+		// act
+		hosts, ok := s.ResolveBatch("actorTypeOne", actorIDs)
+
+		// assert
+		assert.True(t, ok)
+		assert.Len(t, hosts, len(actorIDs))
+		for _, id := range actorIDs {
+			expected, _, _ := s.ResolveActorHost("actorTypeOne", id)
+			assert.Equal(t, expected, hosts[id])
+		}
+		assert.Equal(t, "127.0.0.1:8082", hosts["pinned"])
+	})
+
+	t.Run("unknown entity", func(t *testing.T) {
+		// act
+		hosts, ok := s.ResolveBatch("unknownActorType", actorIDs)
+
+		// assert
+		assert.False(t, ok)
+		assert.Nil(t, hosts)
+	})
+}
+
+func newResolveBenchmarkState() (*DaprHostMemberState, []string) {
+	s := newDaprHostMemberState()
+	s.SetReplicationFactor(100)
+	for i := 0; i < 10; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:%d", 8080+i),
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+
+	actorIDs := make([]string, 500)
+	for i := range actorIDs {
+		actorIDs[i] = fmt.Sprintf("actor%d", i)
+	}
+	return s, actorIDs
+}
+
+func BenchmarkResolveActorHostLoop(b *testing.B) {
+	s, actorIDs := newResolveBenchmarkState()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hosts := make(map[string]string, len(actorIDs))
+		for _, id := range actorIDs {
+			host, _, _ := s.ResolveActorHost("actorTypeOne", id)
+			hosts[id] = host
+		}
+	}
+}
+
+func BenchmarkResolveBatch(b *testing.B) {
+	s, actorIDs := newResolveBenchmarkState()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.ResolveBatch("actorTypeOne", actorIDs)
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	// arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newDaprHostMemberState()
+	s.SetClock(func() time.Time { return now })
+	host := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	}
+
+	t.Run("upsert uses the clock", func(t *testing.T) {
+		// act
+		s.upsertMember(host)
+
+		// assert
+		assert.Equal(t, now, s.Members[host.Name].CreatedAt)
+		assert.Equal(t, now, s.Members[host.Name].UpdatedAt)
+		assert.Equal(t, now, s.RecentEvents()[0].Timestamp)
+	})
+
+	t.Run("update keeps the creation time", func(t *testing.T) {
+		createdAt := now
+		now = now.Add(time.Minute)
+
+		// act
+		s.upsertMember(host)
+
+		// assert
+		assert.Equal(t, createdAt, s.Members[host.Name].CreatedAt)
+		assert.Equal(t, now, s.Members[host.Name].UpdatedAt)
+	})
+
+	t.Run("expiry follows the clock", func(t *testing.T) {
+		// act
+		notExpired := s.expireStaleMembers(time.Minute)
+		now = now.Add(time.Minute + time.Second)
+		expired := s.expireStaleMembers(time.Minute)
+
+		// assert
+		assert.Empty(t, notExpired)
+		assert.Equal(t, []string{host.Name}, expired)
+	})
+
+	t.Run("nil restores the wall clock", func(t *testing.T) {
+		// act
+		s.SetClock(nil)
+		s.upsertMember(host)
+
+		// assert
+		assert.True(t, s.Members[host.Name].CreatedAt.After(now))
+	})
+}
+
+func TestUpsertMemberRequestID(t *testing.T) {
+	// arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newDaprHostMemberState()
+	s.SetClock(func() time.Time { return now })
+	host := &DaprHostMember{
+		Name:      "127.0.0.1:8080",
+		AppID:     "FakeID",
+		Entities:  []string{"actorTypeOne"},
+		RequestID: "req-1",
+	}
+	s.upsertMember(host)
+	registeredAt := now
+
+	t.Run("replayed upsert is ignored", func(t *testing.T) {
+		now = now.Add(time.Minute)
+
+		// act
+		changed, err := s.upsertMember(host)
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, registeredAt, s.Members[host.Name].UpdatedAt)
+		assert.Equal(t, uint64(1), s.Members[host.Name].Version)
+	})
+
+	t.Run("replay with changed content is ignored", func(t *testing.T) {
+		replayed := host.clone()
+		replayed.Entities = []string{"actorTypeTwo"}
+
+		// act
+		wouldChange, _, _ := s.previewUpsert(replayed)
+		changed, err := s.upsertMember(replayed)
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, wouldChange)
+		assert.False(t, changed)
+		assert.Equal(t, []string{"actorTypeOne"}, s.Members[host.Name].Entities)
+	})
+
+	t.Run("new request updates the member", func(t *testing.T) {
+		next := host.clone()
+		next.RequestID = "req-2"
+
+		// act
+		changed, err := s.upsertMember(next)
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, now, s.Members[host.Name].UpdatedAt)
+		assert.Equal(t, "req-2", s.Members[host.Name].RequestID)
+	})
+
+	t.Run("empty request id is never a replay", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		anonymous := host.clone()
+		anonymous.RequestID = ""
+
+		// act
+		s.upsertMember(anonymous)
+		s.upsertMember(anonymous)
+
+		// assert
+		assert.Equal(t, now, s.Members[host.Name].UpdatedAt)
+		assert.Empty(t, s.Members[host.Name].RequestID)
+	})
+}
+
+func TestSetMaxMembers(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.SetMaxMembers(2)
+	for i := 0; i < 2; i++ {
+		_, err := s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+		assert.NoError(t, err)
+	}
+
+	t.Run("new member beyond the limit", func(t *testing.T) {
+		// act
+		changed, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8082",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Error(t, err)
+		assert.Equal(t, ErrMaxMembers, errors.Cause(err))
+		assert.False(t, changed)
+		assert.Len(t, s.Members, 2)
+	})
+
+	t.Run("existing member is updated", func(t *testing.T) {
+		// act
+		changed, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeTwo"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, changed)
+	})
+
+	t.Run("batch beyond the limit", func(t *testing.T) {
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8081"})
+
+		// act
+		_, err := s.upsertMembers([]*DaprHostMember{
+			{Name: "127.0.0.1:8082", AppID: "FakeID"},
+			{Name: "127.0.0.1:8083", AppID: "FakeID"},
+		})
+
+		// assert
+		assert.Equal(t, ErrMaxMembers, errors.Cause(err))
+		assert.Len(t, s.Members, 1)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		s.SetMaxMembers(0)
+
+		// act
+		_, err := s.upsertMembers([]*DaprHostMember{
+			{Name: "127.0.0.1:8082", AppID: "FakeID"},
+			{Name: "127.0.0.1:8083", AppID: "FakeID"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Len(t, s.Members, 3)
+	})
+}
+
+func TestMergeMemberEntities(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	ring := s.hashingTableMap["actorTypeOne"]
+
+	t.Run("unknown host", func(t *testing.T) {
+		assert.False(t, s.mergeMemberEntities("127.0.0.1:9999", []string{"actorTypeTwo"}))
+	})
+
+	t.Run("new entities are added", func(t *testing.T) {
+		generation := s.TableGeneration
+
+		// act
+		changed := s.mergeMemberEntities("127.0.0.1:8080", []string{"actorTypeTwo", "actorTypeOne", "actorTypeThree"})
+
+		// assert
+		assert.True(t, changed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+		m := s.Members["127.0.0.1:8080"]
+		assert.Equal(t, []string{"actorTypeOne", "actorTypeThree", "actorTypeTwo"}, m.Entities)
+		assert.Equal(t, uint64(2), m.Version)
+		assert.Same(t, ring, s.hashingTableMap["actorTypeOne"])
+		assert.Len(t, s.hashingTableMap, 3)
+		assert.Empty(t, s.Verify())
+	})
+
+	t.Run("known entities are not changed", func(t *testing.T) {
+		generation := s.TableGeneration
+
+		// act
+		changed := s.mergeMemberEntities("127.0.0.1:8080", []string{"actorTypeTwo"})
+
+		// assert
+		assert.False(t, changed)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+
+	t.Run("invalid entities", func(t *testing.T) {
+		s.SetAllowedEntities(map[string]struct{}{"actorTypeOne": {}, "actorTypeTwo": {}, "actorTypeThree": {}})
+		defer s.SetAllowedEntities(nil)
+
+		// act
+		changed := s.mergeMemberEntities("127.0.0.1:8080", []string{"actorTypeFour"})
+
+		// assert
+		assert.False(t, changed)
+		assert.Len(t, s.Members["127.0.0.1:8080"].Entities, 3)
+	})
+
+	t.Run("max replicas", func(t *testing.T) {
+		s.SetMaxReplicas(map[string]int{"actorTypeFive": 1})
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeFive"},
+		})
+
+		// act
+		changed := s.mergeMemberEntities("127.0.0.1:8080", []string{"actorTypeFive"})
+
+		// assert
+		assert.False(t, changed)
+		assert.Equal(t, []string{"actorTypeFive"}, s.Members["127.0.0.1:8080"].SkippedEntities)
+		assert.Empty(t, s.Verify())
+	})
+}
+
+func TestUpsertMemberSortedEntities(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	unsorted := &DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeTwo", "actorTypeThree", "actorTypeOne", "actorTypeTwo"},
+	}
+
+	t.Run("entities are stored sorted", func(t *testing.T) {
+		// act
+		changed, err := s.upsertMember(unsorted)
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		expected := []string{"actorTypeOne", "actorTypeThree", "actorTypeTwo"}
+		assert.Equal(t, expected, s.Members["127.0.0.1:8080"].Entities)
+		assert.Equal(t, expected, s.Members["127.0.0.1:8080"].clone().Entities)
+		assert.Equal(t, expected, s.HostEntities("127.0.0.1:8080"))
+		assert.Equal(t, []string{"actorTypeTwo", "actorTypeThree", "actorTypeOne", "actorTypeTwo"}, unsorted.Entities)
+	})
+
+	t.Run("reordering is a no-op", func(t *testing.T) {
+		generation := s.TableGeneration
+		version := s.Members["127.0.0.1:8080"].Version
+
+		// act
+		changed, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeThree", "actorTypeOne", "actorTypeTwo"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, generation, s.TableGeneration)
+		assert.Equal(t, version, s.Members["127.0.0.1:8080"].Version)
+	})
+}
+
+func TestSetMemberEntities(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeTwo"},
+	})
+	ring := s.hashingTableMap["actorTypeOne"]
+
+	t.Run("unknown host", func(t *testing.T) {
+		added, removed, changed := s.setMemberEntities("127.0.0.1:9999", []string{"actorTypeOne"})
+		assert.Empty(t, added)
+		assert.Empty(t, removed)
+		assert.False(t, changed)
+	})
+
+	t.Run("entities are swapped", func(t *testing.T) {
+		generation := s.TableGeneration
+
+		// act
+		added, removed, changed := s.setMemberEntities("127.0.0.1:8080",
+			[]string{"actorTypeThree", "actorTypeOne", "actorTypeFour"})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeFour", "actorTypeThree"}, added)
+		assert.Equal(t, []string{"actorTypeTwo"}, removed)
+		assert.True(t, changed)
+		assert.Equal(t, generation+1, s.TableGeneration)
+		assert.Equal(t, []string{"actorTypeFour", "actorTypeOne", "actorTypeThree"}, s.Members["127.0.0.1:8080"].Entities)
+		assert.Same(t, ring, s.hashingTableMap["actorTypeOne"])
+		assert.Equal(t, []string{"127.0.0.1:8081"}, s.hashingTableMap["actorTypeTwo"].Hosts())
+		assert.Empty(t, s.Verify())
+	})
+
+	t.Run("same entities", func(t *testing.T) {
+		generation := s.TableGeneration
+
+		// act
+		added, removed, changed := s.setMemberEntities("127.0.0.1:8080",
+			[]string{"actorTypeOne", "actorTypeThree", "actorTypeFour", "actorTypeOne"})
+
+		// assert
+		assert.Empty(t, added)
+		assert.Empty(t, removed)
+		assert.False(t, changed)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+
+	t.Run("draining host keeps out of the tables", func(t *testing.T) {
+		s.drainMember("127.0.0.1:8081")
+		generation := s.TableGeneration
+
+		// act
+		added, removed, changed := s.setMemberEntities("127.0.0.1:8081", []string{"actorTypeFive"})
+
+		// assert
+		assert.Equal(t, []string{"actorTypeFive"}, added)
+		assert.Equal(t, []string{"actorTypeTwo"}, removed)
+		assert.False(t, changed)
+		assert.Equal(t, generation, s.TableGeneration)
+		assert.NotContains(t, s.hashingTableMap, "actorTypeFive")
+	})
+
+	t.Run("removing all entities", func(t *testing.T) {
+		// act
+		_, removed, changed := s.setMemberEntities("127.0.0.1:8080", nil)
+
+		// assert
+		assert.Equal(t, []string{"actorTypeFour", "actorTypeOne", "actorTypeThree"}, removed)
+		assert.True(t, changed)
+		assert.Empty(t, s.hashingTableMap)
+		assert.Empty(t, s.Verify())
+	})
+}
+
+func TestFreeze(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	_, err := s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	assert.NoError(t, err)
+	s.Freeze()
+	generation := s.TableGeneration
+
+	t.Run("upsert is rejected", func(t *testing.T) {
+		// act
+		changed, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, ErrFrozen, errors.Cause(err))
+		assert.False(t, changed)
+		assert.Len(t, s.Members, 1)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+
+	t.Run("preview is rejected like upsert", func(t *testing.T) {
+		// act
+		wouldChange, affected, err := s.previewUpsert(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, ErrFrozen, errors.Cause(err))
+		assert.False(t, wouldChange)
+		assert.Empty(t, affected)
+	})
+
+	t.Run("remove is rejected", func(t *testing.T) {
+		// act
+		changed, err := s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+
+		// assert
+		assert.Equal(t, ErrFrozen, errors.Cause(err))
+		assert.False(t, changed)
+		assert.Contains(t, s.Members, "127.0.0.1:8080")
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+
+	t.Run("reads still work", func(t *testing.T) {
+		// act
+		host, _, ok := s.ResolveActorHost("actorTypeOne", "actor1")
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, "127.0.0.1:8080", host)
+	})
+
+	t.Run("unfreeze allows mutations", func(t *testing.T) {
+		s.Unfreeze()
+
+		// act
+		changed, err := s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		assert.Empty(t, s.Members)
+	})
+}
+
+func TestAliases(t *testing.T) {
+	// arrange
+	hashing.SetReplicationFactor(10)
+	defer hashing.SetReplicationFactor(0)
+
+	s := newDaprHostMemberState()
+	for i := 0; i < 3; i++ {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:808%d", i),
+			AppID:    fmt.Sprintf("FakeID_%d", i),
+			Entities: []string{"actorTypeNew"},
+		})
+	}
+	owner, _, _ := s.ResolveActorHost("actorTypeNew", "actor1")
+	generation := s.TableGeneration
+
+	t.Run("alias resolves through the canonical ring", func(t *testing.T) {
+		// act
+		assert.NoError(t, s.AddAlias("actorTypeOld", "actorTypeNew"))
+		host, _, ok := s.ResolveActorHost("actorTypeOld", "actor1")
+		hosts, batchOK := s.ResolveBatch("actorTypeOld", []string{"actor1"})
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, owner, host)
+		assert.True(t, batchOK)
+		assert.Equal(t, map[string]string{"actor1": owner}, hosts)
+		assert.NotContains(t, s.hashingTableMap, "actorTypeOld")
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+
+	t.Run("aliases survive clone", func(t *testing.T) {
+		// act
+		cloned := s.clone()
+
+		// assert
+		assert.Equal(t, s.Aliases, cloned.Aliases)
+	})
+
+	t.Run("remove alias", func(t *testing.T) {
+		// act
+		removed := s.RemoveAlias("actorTypeOld")
+		removedAgain := s.RemoveAlias("actorTypeOld")
+		_, _, ok := s.ResolveActorHost("actorTypeOld", "actor1")
+
+		// assert
+		assert.True(t, removed)
+		assert.False(t, removedAgain)
+		assert.False(t, ok)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+}
+
+func TestUpsertMemberResult(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+
+	testcases := []struct {
+		name             string
+		host             *DaprHostMember
+		expectedReason   UpsertReason
+		expectedEntities []string
+	}{
+		{
+			name:             "new actor host",
+			host:             &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}},
+			expectedReason:   UpsertReasonNewHost,
+			expectedEntities: []string{"actorTypeOne"},
+		},
+		{
+			name:             "heartbeat",
+			host:             &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID", Entities: []string{"actorTypeOne"}},
+			expectedReason:   UpsertReasonUnchanged,
+			expectedEntities: []string{},
+		},
+		{
+			name:             "app id change",
+			host:             &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID_2", Entities: []string{"actorTypeOne"}},
+			expectedReason:   UpsertReasonAppIDChanged,
+			expectedEntities: []string{"actorTypeOne"},
+		},
+		{
+			name:             "entities change",
+			host:             &DaprHostMember{Name: "127.0.0.1:8080", AppID: "FakeID_2", Entities: []string{"actorTypeOne", "actorTypeTwo"}},
+			expectedReason:   UpsertReasonEntitiesChanged,
+			expectedEntities: []string{"actorTypeOne", "actorTypeTwo"},
+		},
+		{
+			name:             "new non actor host",
+			host:             &DaprHostMember{Name: "127.0.0.1:8081", AppID: "FakeID"},
+			expectedReason:   UpsertReasonNonActorHost,
+			expectedEntities: []string{},
+		},
+		{
+			name:             "first request",
+			host:             &DaprHostMember{Name: "127.0.0.1:8081", AppID: "FakeID", Entities: []string{"actorTypeOne"}, RequestID: "req1"},
+			expectedReason:   UpsertReasonEntitiesChanged,
+			expectedEntities: []string{"actorTypeOne"},
+		},
+		{
+			name:             "replayed request",
+			host:             &DaprHostMember{Name: "127.0.0.1:8081", AppID: "FakeID", Entities: []string{"actorTypeOne"}, RequestID: "req1"},
+			expectedReason:   UpsertReasonReplayed,
+			expectedEntities: []string{},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// act
+			result, err := s.upsertMemberResult(tc.host)
+
+			// assert
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReason, result.Reason)
+			assert.Equal(t, tc.expectedEntities, result.Entities)
+			assert.Equal(t, len(tc.expectedEntities) > 0, result.TableChanged())
+		})
+	}
+
+	t.Run("invalid member", func(t *testing.T) {
+		// act
+		result, err := s.upsertMemberResult(&DaprHostMember{Name: "127.0.0.1:8082", Entities: []string{""}})
+
+		// assert
+		assert.Error(t, err)
+		assert.Equal(t, UpsertReason(""), result.Reason)
+		assert.False(t, result.TableChanged())
+	})
+}
+
+func TestHeartbeat(t *testing.T) {
+	// arrange
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newDaprHostMemberState()
+	s.SetClock(func() time.Time { return now })
+	s.upsertMember(&DaprHostMember{
+		Name:  "127.0.0.1:8080",
+		AppID: "FakeID",
+	})
+	generation := s.TableGeneration
+	version := s.Members["127.0.0.1:8080"].Version
+
+	t.Run("existing member", func(t *testing.T) {
+		now = now.Add(time.Minute)
+
+		// act
+		ok := s.heartbeat("127.0.0.1:8080")
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, now, s.Members["127.0.0.1:8080"].UpdatedAt)
+		assert.Equal(t, version, s.Members["127.0.0.1:8080"].Version)
+		assert.Equal(t, generation, s.TableGeneration)
+	})
+
+	t.Run("unknown member", func(t *testing.T) {
+		// act
+		ok := s.heartbeat("127.0.0.1:8081")
+
+		// assert
+		assert.False(t, ok)
+		assert.NotContains(t, s.Members, "127.0.0.1:8081")
+	})
+
+	t.Run("frozen state", func(t *testing.T) {
+		s.Freeze()
+		defer s.Unfreeze()
+		updatedAt := s.Members["127.0.0.1:8080"].UpdatedAt
+		now = now.Add(time.Minute)
+
+		// act
+		ok := s.heartbeat("127.0.0.1:8080")
+
+		// assert
+		assert.False(t, ok)
+		assert.Equal(t, updatedAt, s.Members["127.0.0.1:8080"].UpdatedAt)
+	})
+}
+
+func TestSetMutationValidator(t *testing.T) {
+	// arrange
+	errMissingLabel := errors.New("missing the region label")
+	s := newDaprHostMemberState()
+	s.SetMutationValidator(func(m *DaprHostMember) error {
+		if m.Labels["region"] == "" {
+			return errMissingLabel
+		}
+		return nil
+	})
+
+	t.Run("rejected member", func(t *testing.T) {
+		// act
+		changed, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, errMissingLabel, errors.Cause(err))
+		assert.False(t, changed)
+		assert.Empty(t, s.Members)
+		assert.Equal(t, uint64(0), s.TableGeneration)
+	})
+
+	t.Run("rejected batch", func(t *testing.T) {
+		// act
+		_, err := s.upsertMembers([]*DaprHostMember{
+			{Name: "127.0.0.1:8080", AppID: "FakeID", Labels: map[string]string{"region": "west"}},
+			{Name: "127.0.0.1:8081", AppID: "FakeID"},
+		})
+
+		// assert
+		assert.Equal(t, errMissingLabel, errors.Cause(err))
+		assert.Empty(t, s.Members)
+	})
+
+	t.Run("accepted member", func(t *testing.T) {
+		// act
+		changed, err := s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+			Labels:   map[string]string{"region": "west"},
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.True(t, changed)
+	})
+
+	t.Run("nil validator accepts all members", func(t *testing.T) {
+		s.SetMutationValidator(nil)
+
+		// act
+		_, err := s.upsertMember(&DaprHostMember{
+			Name:  "127.0.0.1:8081",
+			AppID: "FakeID",
+		})
+
+		// assert
+		assert.NoError(t, err)
+		assert.Len(t, s.Members, 2)
+	})
+}
+
+func TestGenerationWindow(t *testing.T) {
+	upsertHost := func(s *DaprHostMemberState, i int) {
+		s.upsertMember(&DaprHostMember{
+			Name:     fmt.Sprintf("127.0.0.1:%d", 8080+i),
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+	}
+
+	t.Run("changes within the window are coalesced", func(t *testing.T) {
+		// arrange
+		s := newDaprHostMemberState()
+		s.SetGenerationWindow(10)
+		upsertHost(s, 0)
+		s.setIndex(1)
+		assert.Equal(t, uint64(1), s.LastGeneration())
+
+		// act
+		upsertHost(s, 1)
+		s.setIndex(2)
+		upsertHost(s, 2)
+		s.setIndex(3)
+
+		// assert
+		assert.Equal(t, uint64(1), s.LastGeneration())
+		assert.True(t, s.PendingGeneration())
+		assert.Equal(t, 3, s.hashingTableMap["actorTypeOne"].HostCount())
+
+		assert.True(t, s.flushPending())
+		assert.False(t, s.flushPending())
+		assert.False(t, s.PendingGeneration())
+		assert.Equal(t, uint64(2), s.LastGeneration())
+		delta, ok := s.TableDelta(1)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"127.0.0.1:8081", "127.0.0.1:8082"}, delta.Added["actorTypeOne"])
+	})
+
+	t.Run("change after the window is applied immediately", func(t *testing.T) {
+		// arrange
+		s := newDaprHostMemberState()
+		s.SetGenerationWindow(2)
+		upsertHost(s, 0)
+		s.setIndex(1)
+		s.setIndex(2)
+
+		// act
+		upsertHost(s, 1)
+
+		// assert
+		assert.Equal(t, uint64(2), s.LastGeneration())
+		assert.False(t, s.flushPending())
+	})
+
+	t.Run("log entry after the window applies the pending change", func(t *testing.T) {
+		// arrange
+		s := newDaprHostMemberState()
+		s.SetGenerationWindow(3)
+		upsertHost(s, 0)
+		s.setIndex(1)
+		upsertHost(s, 1)
+
+		// act
+		s.setIndex(2)
+		pendingBefore := s.PendingGeneration()
+		s.setIndex(3)
+
+		// assert
+		assert.True(t, pendingBefore)
+		assert.False(t, s.PendingGeneration())
+		assert.Equal(t, uint64(2), s.LastGeneration())
+	})
+
+	t.Run("disabling the window applies the pending change at the next entry", func(t *testing.T) {
+		// arrange
+		s := newDaprHostMemberState()
+		s.SetGenerationWindow(10)
+		upsertHost(s, 0)
+		s.setIndex(1)
+		upsertHost(s, 1)
+		s.setIndex(2)
+
+		// act
+		s.SetGenerationWindow(0)
+		generationBefore := s.LastGeneration()
+		s.setIndex(3)
+
+		// assert
+		assert.Equal(t, uint64(1), generationBefore)
+		assert.Equal(t, uint64(2), s.LastGeneration())
+	})
+}
@@ -0,0 +1,277 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"sort"
+	"time"
+)
+
+// tableHistorySize is the number of recent table generations from which
+// TableDelta can compute a delta.
+const tableHistorySize = 64
+
+// Delta is the change of the hashing tables between two table generations.
+// A host which is changed, such as its weight or app id, is both removed and
+// added.
+type Delta struct {
+	FromGeneration uint64 `json:"fromGeneration"`
+	ToGeneration   uint64 `json:"toGeneration"`
+	// Added is the sorted host names added per hashing table key.
+	Added map[string][]string `json:"added"`
+	// Removed is the sorted host names removed per hashing table key.
+	Removed map[string][]string `json:"removed"`
+}
+
+// ringOp is an addition or removal of a host in the hashing table of key.
+type ringOp struct {
+	key       string
+	namespace string
+	host      string
+	added     bool
+}
+
+// tableChange is the ring operations which lead to the table generation.
+type tableChange struct {
+	generation uint64
+	ops        []ringOp
+}
+
+// recordRingOp records the ring operation of the next table generation.
+// The caller must hold the write lock.
+func (s *DaprHostMemberState) recordRingOp(key, namespace, host string, added bool) {
+	s.pendingRingOps = append(s.pendingRingOps, ringOp{key: key, namespace: namespace, host: host, added: added})
+}
+
+// commitRingOps stores the pending ring operations as the change of the
+// current table generation. The caller must hold the write lock.
+func (s *DaprHostMemberState) commitRingOps() {
+	s.updateHostSetGenerations(s.pendingRingOps)
+	s.recordRebalance()
+	s.updateEntityChangedAt(s.pendingRingOps, s.now())
+	s.updateNamespaceGenerations(s.pendingRingOps)
+	s.tableHistory = append(s.tableHistory, tableChange{
+		generation: s.TableGeneration,
+		ops:        s.pendingRingOps,
+	})
+	if len(s.tableHistory) > tableHistorySize {
+		s.tableHistory = s.tableHistory[len(s.tableHistory)-tableHistorySize:]
+	}
+	s.pendingRingOps = nil
+}
+
+// TableDelta returns the change of the hashing tables from the table
+// generation fromGen to the current table generation. ok is false when
+// fromGen is not in the recent history, so that callers must fall back to
+// the full placement tables.
+func (s *DaprHostMemberState) TableDelta(fromGen uint64) (*Delta, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if fromGen > s.TableGeneration {
+		return nil, false
+	}
+
+	d := &Delta{
+		FromGeneration: fromGen,
+		ToGeneration:   s.TableGeneration,
+		Added:          map[string][]string{},
+		Removed:        map[string][]string{},
+	}
+	if fromGen == s.TableGeneration {
+		return d, true
+	}
+
+	i := sort.Search(len(s.tableHistory), func(i int) bool {
+		return s.tableHistory[i].generation > fromGen
+	})
+	if i == len(s.tableHistory) || s.tableHistory[i].generation != fromGen+1 {
+		return nil, false
+	}
+
+	// existed is whether the host was in the table at fromGen, and exists
+	// whether it is in the table now.
+	type hostChange struct {
+		existed bool
+		exists  bool
+	}
+	changes := map[ringOp]*hostChange{}
+	for _, c := range s.tableHistory[i:] {
+		for _, op := range c.ops {
+			k := ringOp{key: op.key, host: op.host}
+			if _, ok := changes[k]; !ok {
+				changes[k] = &hostChange{existed: !op.added}
+			}
+			changes[k].exists = op.added
+		}
+	}
+
+	for k, c := range changes {
+		if c.existed {
+			d.Removed[k.key] = append(d.Removed[k.key], k.host)
+		}
+		if c.exists {
+			d.Added[k.key] = append(d.Added[k.key], k.host)
+		}
+	}
+	for _, hosts := range d.Added {
+		sort.Strings(hosts)
+	}
+	for _, hosts := range d.Removed {
+		sort.Strings(hosts)
+	}
+	return d, true
+}
+
+// updateHostSetGenerations sets the host set generation of the hashing tables
+// whose distinct hosts are changed by the ring operations to the current
+// table generation. The caller must hold the write lock.
+func (s *DaprHostMemberState) updateHostSetGenerations(ops []ringOp) {
+	// in maps a host of a table to whether it is in the table, and existed
+	// whether it was in the table before the operations.
+	in := map[ringOp]bool{}
+	existed := map[ringOp]bool{}
+	for _, op := range ops {
+		k := ringOp{key: op.key, host: op.host}
+		if _, ok := in[k]; !ok {
+			existed[k] = !op.added
+		}
+		in[k] = op.added
+	}
+
+	for k, exists := range in {
+		if exists == existed[k] {
+			continue
+		}
+		if _, ok := s.hashingTableMap[k.key]; !ok {
+			delete(s.hostSetGenerations, k.key)
+			continue
+		}
+		if s.hostSetGenerations == nil {
+			s.hostSetGenerations = map[string]uint64{}
+		}
+		s.hostSetGenerations[k.key] = s.TableGeneration
+	}
+}
+
+// resetHostSetGenerations sets the host set generation of the hashing tables
+// of the keys to the current table generation, after the tables are rebuilt.
+// The caller must hold the write lock.
+func (s *DaprHostMemberState) resetHostSetGenerations(keys map[string]struct{}) {
+	if s.hostSetGenerations == nil {
+		s.hostSetGenerations = map[string]uint64{}
+	}
+	for key := range keys {
+		if _, ok := s.hashingTableMap[key]; ok {
+			s.hostSetGenerations[key] = s.TableGeneration
+		} else {
+			delete(s.hostSetGenerations, key)
+		}
+	}
+}
+
+// restoreGenerations seeds Index, TableGeneration, the generation window and
+// the host set generation of each hashing table from the checkpoint persisted
+// in the snapshot, after
+// the hashing tables are rebuilt, so that they match what clients last saw
+// instead of the rebuild marking every table as changed. Tables missing from
+// the HostSetGenerations checkpoint, such as in older snapshots, and
+// generations beyond the given generation are set to the given generation.
+func (s *DaprHostMemberState) restoreGenerations(index, generation uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.seedGenerations(index, generation)
+}
+
+// seedGenerations is restoreGenerations with the write lock held.
+func (s *DaprHostMemberState) seedGenerations(index, generation uint64) {
+	s.Index = index
+	s.TableGeneration = generation
+	s.generationPending = s.GenerationPending
+	s.lastGenerationIndex = s.LastGenerationIndex
+	s.GenerationPending = false
+	s.LastGenerationIndex = 0
+	s.hostSetGenerations = make(map[string]uint64, len(s.hashingTableMap))
+	for key := range s.hashingTableMap {
+		g, ok := s.HostSetGenerations[key]
+		if !ok || g > generation {
+			g = generation
+		}
+		s.hostSetGenerations[key] = g
+	}
+	s.HostSetGenerations = nil
+}
+
+// EntityHostSetGeneration returns the table generation at which the distinct
+// hosts of the hashing table of the key last changed. Unlike TableGeneration,
+// it doesn't change when only the weights of the hosts change. It returns
+// zero when the table doesn't exist.
+func (s *DaprHostMemberState) EntityHostSetGeneration(entity string) uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.hostSetGenerations[entity]
+}
+
+// updateEntityChangedAt sets the last change time of the hashing tables
+// updated by the ring operations, and deletes it for the deleted tables.
+// Rebuilding the tables doesn't commit ring operations, so the times survive
+// restores. The caller must hold the write lock.
+func (s *DaprHostMemberState) updateEntityChangedAt(ops []ringOp, now time.Time) {
+	for _, op := range ops {
+		if _, ok := s.hashingTableMap[op.key]; !ok {
+			delete(s.EntityChangedAt, op.key)
+			continue
+		}
+		if s.EntityChangedAt == nil {
+			s.EntityChangedAt = map[string]time.Time{}
+		}
+		s.EntityChangedAt[op.key] = now
+	}
+}
+
+// EntityLastChanged returns the last time when the hashing table of the key
+// changed, so that clients can refresh only the tables they use. ok is false
+// if the hashing table doesn't exist or has not changed since it was loaded
+// from a state without the change times.
+func (s *DaprHostMemberState) EntityLastChanged(entity string) (time.Time, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if _, ok := s.hashingTableMap[entity]; !ok {
+		return time.Time{}, false
+	}
+	t, ok := s.EntityChangedAt[entity]
+	return t, ok
+}
+
+// updateNamespaceGenerations increases the generations of the namespaces of
+// the ring operations once. The caller must hold the write lock.
+func (s *DaprHostMemberState) updateNamespaceGenerations(ops []ringOp) {
+	updated := map[string]struct{}{}
+	for _, op := range ops {
+		if _, ok := updated[op.namespace]; ok {
+			continue
+		}
+		updated[op.namespace] = struct{}{}
+		if s.NamespaceGenerations == nil {
+			s.NamespaceGenerations = map[string]uint64{}
+		}
+		s.NamespaceGenerations[op.namespace]++
+	}
+}
+
+// NamespaceGeneration returns the generation of the hashing tables of the
+// namespace, so that the tables can be disseminated per namespace. Unlike
+// TableGeneration, it doesn't change when only the tables of other namespaces
+// are updated. The empty namespace is the namespace of the hosts without it.
+func (s *DaprHostMemberState) NamespaceGeneration(ns string) uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.NamespaceGenerations[ns]
+}
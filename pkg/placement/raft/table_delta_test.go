@@ -0,0 +1,299 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableDelta(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne"},
+	})
+	base := s.TableGeneration
+
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+
+	t.Run("delta since a recent generation", func(t *testing.T) {
+		// act
+		d, ok := s.TableDelta(base)
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, base, d.FromGeneration)
+		assert.Equal(t, s.TableGeneration, d.ToGeneration)
+		assert.Equal(t, map[string][]string{
+			"actorTypeOne": {"127.0.0.1:8081"},
+			"actorTypeTwo": {"127.0.0.1:8081"},
+		}, d.Added)
+		assert.Equal(t, map[string][]string{
+			"actorTypeOne": {"127.0.0.1:8080"},
+		}, d.Removed)
+	})
+
+	t.Run("host added and removed within the delta", func(t *testing.T) {
+		// act
+		d, ok := s.TableDelta(0)
+
+		// assert
+		assert.True(t, ok)
+		assert.NotContains(t, d.Added["actorTypeOne"], "127.0.0.1:8080")
+		assert.Empty(t, d.Removed)
+	})
+
+	t.Run("changed host is removed and added", func(t *testing.T) {
+		from := s.TableGeneration
+
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+			Weight:   2,
+		})
+		d, ok := s.TableDelta(from)
+
+		// assert
+		assert.True(t, ok)
+		assert.Equal(t, []string{"127.0.0.1:8081"}, d.Added["actorTypeOne"])
+		assert.Equal(t, []string{"127.0.0.1:8081"}, d.Removed["actorTypeOne"])
+	})
+
+	t.Run("current generation", func(t *testing.T) {
+		d, ok := s.TableDelta(s.TableGeneration)
+		assert.True(t, ok)
+		assert.Empty(t, d.Added)
+		assert.Empty(t, d.Removed)
+	})
+
+	t.Run("future generation", func(t *testing.T) {
+		_, ok := s.TableDelta(s.TableGeneration + 1)
+		assert.False(t, ok)
+	})
+
+	t.Run("generation older than the history", func(t *testing.T) {
+		// act
+		for i := 0; i < tableHistorySize; i++ {
+			s.drainMember("127.0.0.1:8081")
+			s.undrainMember("127.0.0.1:8081")
+		}
+		_, ok := s.TableDelta(base)
+
+		// assert
+		assert.False(t, ok)
+	})
+
+	t.Run("restored state has no history", func(t *testing.T) {
+		// act
+		c := s.clone()
+		c.restoreHashingTables()
+		_, ok := c.TableDelta(c.TableGeneration - 1)
+
+		// assert
+		assert.False(t, ok)
+	})
+}
+
+func TestEntityHostSetGeneration(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8081",
+		AppID:    "FakeID_2",
+		Entities: []string{"actorTypeOne"},
+	})
+
+	t.Run("host set changes", func(t *testing.T) {
+		assert.Equal(t, uint64(2), s.EntityHostSetGeneration("actorTypeOne"))
+		assert.Equal(t, uint64(1), s.EntityHostSetGeneration("actorTypeTwo"))
+	})
+
+	t.Run("weight change keeps the host set generation", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeOne"},
+			Weight:   2,
+		})
+
+		// assert
+		assert.Equal(t, uint64(3), s.TableGeneration)
+		assert.Equal(t, uint64(2), s.EntityHostSetGeneration("actorTypeOne"))
+	})
+
+	t.Run("removed table", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, uint64(2), s.EntityHostSetGeneration("actorTypeOne"))
+		assert.Equal(t, uint64(0), s.EntityHostSetGeneration("actorTypeTwo"))
+	})
+
+	t.Run("restored tables start at the table generation", func(t *testing.T) {
+		// act
+		c := s.clone()
+		c.restoreHashingTables()
+
+		// assert
+		assert.Equal(t, s.TableGeneration, c.EntityHostSetGeneration("actorTypeOne"))
+	})
+}
+
+func TestEntityLastChanged(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	s.upsertMember(&DaprHostMember{
+		Name:     "127.0.0.1:8080",
+		AppID:    "FakeID",
+		Entities: []string{"actorTypeOne", "actorTypeTwo"},
+	})
+	changedAt, ok := s.EntityLastChanged("actorTypeOne")
+	assert.True(t, ok)
+	assert.False(t, changedAt.IsZero())
+
+	t.Run("only updated tables change", func(t *testing.T) {
+		time.Sleep(time.Millisecond)
+
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeTwo"},
+		})
+
+		// assert
+		one, _ := s.EntityLastChanged("actorTypeOne")
+		two, _ := s.EntityLastChanged("actorTypeTwo")
+		assert.Equal(t, changedAt, one)
+		assert.True(t, two.After(changedAt))
+	})
+
+	t.Run("restore keeps the times", func(t *testing.T) {
+		c := s.clone()
+
+		// act
+		c.restoreHashingTables()
+
+		// assert
+		one, ok := c.EntityLastChanged("actorTypeOne")
+		assert.True(t, ok)
+		assert.Equal(t, changedAt, one)
+	})
+
+	t.Run("serialization keeps the times", func(t *testing.T) {
+		// act
+		data, err := s.MarshalProto()
+		assert.NoError(t, err)
+		fromProto := newDaprHostMemberState()
+		assert.NoError(t, fromProto.UnmarshalProto(data))
+		data, err = s.MarshalState()
+		assert.NoError(t, err)
+		fromJSON, err := LoadState(data)
+		assert.NoError(t, err)
+
+		// assert
+		for _, loaded := range []*DaprHostMemberState{fromProto, fromJSON} {
+			one, ok := loaded.EntityLastChanged("actorTypeOne")
+			assert.True(t, ok)
+			assert.True(t, changedAt.Equal(one))
+		}
+	})
+
+	t.Run("deleted table", func(t *testing.T) {
+		// act
+		s.removeMember(&DaprHostMember{Name: "127.0.0.1:8080"})
+
+		// assert
+		_, ok := s.EntityLastChanged("actorTypeOne")
+		assert.False(t, ok)
+		assert.NotContains(t, s.EntityChangedAt, "actorTypeOne")
+	})
+}
+
+func TestNamespaceGeneration(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+
+	t.Run("only updated namespaces change", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:      "127.0.0.1:8080",
+			AppID:     "FakeID",
+			Namespace: "ns1",
+			Entities:  []string{"actorTypeOne", "actorTypeTwo"},
+		})
+		s.upsertMember(&DaprHostMember{
+			Name:      "127.0.0.1:8081",
+			AppID:     "FakeID_2",
+			Namespace: "ns2",
+			Entities:  []string{"actorTypeOne"},
+		})
+		s.upsertMember(&DaprHostMember{
+			Name:      "127.0.0.1:8082",
+			AppID:     "FakeID_3",
+			Namespace: "ns2",
+			Entities:  []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, uint64(3), s.TableGeneration)
+		assert.Equal(t, uint64(1), s.NamespaceGeneration("ns1"))
+		assert.Equal(t, uint64(2), s.NamespaceGeneration("ns2"))
+		assert.Equal(t, uint64(0), s.NamespaceGeneration(""))
+	})
+
+	t.Run("moving a host changes both namespaces", func(t *testing.T) {
+		// act
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8082",
+			AppID:    "FakeID_3",
+			Entities: []string{"actorTypeOne"},
+		})
+
+		// assert
+		assert.Equal(t, uint64(1), s.NamespaceGeneration("ns1"))
+		assert.Equal(t, uint64(3), s.NamespaceGeneration("ns2"))
+		assert.Equal(t, uint64(1), s.NamespaceGeneration(""))
+	})
+
+	t.Run("generations are persisted", func(t *testing.T) {
+		// act
+		c := s.clone()
+		c.restoreHashingTables()
+		data, err := s.MarshalProto()
+		assert.NoError(t, err)
+		fromProto := newDaprHostMemberState()
+		assert.NoError(t, fromProto.UnmarshalProto(data))
+
+		// assert
+		for _, loaded := range []*DaprHostMemberState{c, fromProto} {
+			assert.Equal(t, uint64(3), loaded.NamespaceGeneration("ns2"))
+		}
+	})
+}
@@ -0,0 +1,101 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Verify cross-checks Members with the hashing tables and returns an error
+// per discrepancy. It reports actor hosts missing from the tables of their
+// entities and hosts in the tables which are unknown or don't declare the entity.
+func (s *DaprHostMemberState) Verify() []error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	errs := []error{}
+
+	names := make([]string, 0, len(s.Members))
+	for name := range s.Members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// declared maps hashing table key to the hosts which must be in the table.
+	declared := map[string]map[string]struct{}{}
+	for _, name := range names {
+		m := s.Members[name]
+		if !s.servesHashingTables(m) {
+			continue
+		}
+		for _, key := range m.entityKeys() {
+			if _, ok := declared[key]; !ok {
+				declared[key] = map[string]struct{}{}
+			}
+			declared[key][name] = struct{}{}
+
+			t, ok := s.hashingTableMap[key]
+			if !ok {
+				errs = append(errs, errors.Errorf("host %s declares %s, but no hashing table exists", name, key))
+				continue
+			}
+			if _, _, loadMap, _ := t.GetInternals(); loadMap[name] == nil {
+				errs = append(errs, errors.Errorf("host %s declares %s, but is missing from its hashing table", name, key))
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(s.hashingTableMap))
+	for key := range s.hashingTableMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		hosts := s.hashingTableMap[key].Hosts()
+		if len(hosts) == 0 {
+			errs = append(errs, errors.Errorf("hashing table %s has no hosts", key))
+			continue
+		}
+		sort.Strings(hosts)
+		for _, h := range hosts {
+			m, ok := s.Members[h]
+			switch {
+			case !ok:
+				errs = append(errs, errors.Errorf("hashing table %s contains unknown host %s", key, h))
+			case m.Draining:
+				errs = append(errs, errors.Errorf("hashing table %s contains draining host %s", key, h))
+			default:
+				if _, ok := declared[key][h]; !ok {
+					errs = append(errs, errors.Errorf("hashing table %s contains host %s which doesn't declare it", key, h))
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// StaleMembers returns the sorted names of the members which are not updated
+// within the threshold. These are likely hosts whose removal was missed.
+// Unlike expireStaleMembers, it doesn't remove them.
+func (s *DaprHostMemberState) StaleMembers(threshold time.Duration) []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	now := s.now()
+	stale := []string{}
+	for name, m := range s.Members {
+		if now.Sub(m.UpdatedAt) > threshold {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
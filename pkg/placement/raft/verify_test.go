@@ -0,0 +1,132 @@
+// ------------------------------------------------------------
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+// ------------------------------------------------------------
+
+package raft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dapr/dapr/pkg/placement/hashing"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	newTestState := func() *DaprHostMemberState {
+		s := newDaprHostMemberState()
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8080",
+			AppID:    "FakeID",
+			Entities: []string{"actorTypeOne", "actorTypeTwo"},
+		})
+		s.upsertMember(&DaprHostMember{
+			Name:     "127.0.0.1:8081",
+			AppID:    "FakeID_2",
+			Entities: []string{"actorTypeOne"},
+		})
+		return s
+	}
+
+	t.Run("consistent state", func(t *testing.T) {
+		s := newTestState()
+		s.drainMember("127.0.0.1:8081")
+		assert.Empty(t, s.Verify())
+	})
+
+	t.Run("host missing from hashing table", func(t *testing.T) {
+		s := newTestState()
+		s.hashingTableMap["actorTypeOne"].Remove("127.0.0.1:8081")
+
+		errs := s.Verify()
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "missing from its hashing table")
+	})
+
+	t.Run("missing hashing table", func(t *testing.T) {
+		s := newTestState()
+		delete(s.hashingTableMap, "actorTypeTwo")
+
+		errs := s.Verify()
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "no hashing table exists")
+	})
+
+	t.Run("unknown and undeclared hosts in hashing table", func(t *testing.T) {
+		s := newTestState()
+		s.hashingTableMap["actorTypeTwo"].Add("127.0.0.1:8081", "FakeID_2", 0)
+		s.hashingTableMap["actorTypeTwo"].Add("127.0.0.1:9999", "FakeID_3", 0)
+
+		errs := s.Verify()
+		assert.Equal(t, 2, len(errs))
+		assert.Contains(t, errs[0].Error(), "doesn't declare it")
+		assert.Contains(t, errs[1].Error(), "unknown host")
+	})
+
+	t.Run("empty hashing table", func(t *testing.T) {
+		s := newTestState()
+		s.hashingTableMap["actorTypeThree"] = hashing.NewConsistentHash()
+
+		errs := s.Verify()
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "has no hosts")
+	})
+}
+
+func TestStaleMembers(t *testing.T) {
+	// arrange
+	s := newDaprHostMemberState()
+	for _, name := range []string{"127.0.0.1:8081", "127.0.0.1:8080", "127.0.0.1:8082"} {
+		s.upsertMember(&DaprHostMember{Name: name, AppID: "FakeID"})
+	}
+	s.Members["127.0.0.1:8081"].UpdatedAt = time.Now().UTC().Add(-time.Hour)
+	s.Members["127.0.0.1:8080"].UpdatedAt = time.Now().UTC().Add(-time.Hour)
+
+	// act
+	stale := s.StaleMembers(time.Minute)
+
+	// assert
+	assert.Equal(t, []string{"127.0.0.1:8080", "127.0.0.1:8081"}, stale)
+	assert.Equal(t, 3, len(s.Members))
+	assert.Empty(t, s.StaleMembers(2*time.Hour))
+}
+
+// invariantChecker checks the invariants of a state across a sequence of
+// mutations, such as the random sequences applied by the property tests.
+type invariantChecker struct {
+	lastGeneration uint64
+}
+
+func newInvariantChecker(s *DaprHostMemberState) *invariantChecker {
+	return &invariantChecker{lastGeneration: s.TableGeneration}
+}
+
+// check returns the discrepancies reported by Verify and an error if
+// TableGeneration didn't increment by exactly one when tableChanged is true
+// and stay the same otherwise, or if a clone of the state isn't Equal to it.
+func (c *invariantChecker) check(s *DaprHostMemberState, tableChanged bool) []error {
+	errs := s.Verify()
+
+	s.lock.RLock()
+	generation := s.TableGeneration
+	s.lock.RUnlock()
+
+	expected := c.lastGeneration
+	if tableChanged {
+		expected++
+	}
+	if generation != expected {
+		errs = append(errs, errors.Errorf("table generation is %d, expected %d", generation, expected))
+	}
+	if generation > c.lastGeneration {
+		c.lastGeneration = generation
+	}
+
+	if !s.Equal(s.cloneWithTables()) {
+		errs = append(errs, errors.New("clone of the state isn't equal to it"))
+	}
+
+	return errs
+}
@@ -0,0 +1,431 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: dapr/proto/placement/v1/placement_state.proto
+
+package placement
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// PlacementState is the serialized membership state of the placement service.
+// The consistent hashing tables are not serialized and are rebuilt from the
+// members on load.
+type PlacementState struct {
+	// schema_version is the version of the state layout.
+	SchemaVersion   uint32 `protobuf:"varint,1,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	Index           uint64 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	TableGeneration uint64 `protobuf:"varint,3,opt,name=table_generation,json=tableGeneration,proto3" json:"table_generation,omitempty"`
+	// members are sorted by name.
+	Members []*PlacementMember `protobuf:"bytes,4,rep,name=members,proto3" json:"members,omitempty"`
+	// entity_changed_at is the last change time of each hashing table in unix
+	// nanoseconds. The key is the hashing table key.
+	EntityChangedAt map[string]int64 `protobuf:"bytes,5,rep,name=entity_changed_at,json=entityChangedAt,proto3" json:"entity_changed_at,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// namespace_generations is the generation of the hashing tables of each
+	// namespace.
+	NamespaceGenerations map[string]uint64 `protobuf:"bytes,6,rep,name=namespace_generations,json=namespaceGenerations,proto3" json:"namespace_generations,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// pins are the pinned actors of each hashing table key.
+	Pins map[string]*PlacementPins `protobuf:"bytes,7,rep,name=pins,proto3" json:"pins,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// aliases maps the alias of an Actor Type to its canonical Actor Type.
+	Aliases map[string]string `protobuf:"bytes,8,rep,name=aliases,proto3" json:"aliases,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// host_set_generations is the checkpoint of the host set generation of each
+	// hashing table key.
+	HostSetGenerations map[string]uint64 `protobuf:"bytes,9,rep,name=host_set_generations,json=hostSetGenerations,proto3" json:"host_set_generations,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// orphaned_at is the time when the last host left the hashing table of each
+	// key in unix nanoseconds.
+	OrphanedAt map[string]int64 `protobuf:"bytes,10,rep,name=orphaned_at,json=orphanedAt,proto3" json:"orphaned_at,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// generation_pending and last_generation_index are the checkpoint of the
+	// generation window.
+	GenerationPending    bool     `protobuf:"varint,11,opt,name=generation_pending,json=generationPending,proto3" json:"generation_pending,omitempty"`
+	LastGenerationIndex  uint64   `protobuf:"varint,12,opt,name=last_generation_index,json=lastGenerationIndex,proto3" json:"last_generation_index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PlacementState) Reset()         { *m = PlacementState{} }
+func (m *PlacementState) String() string { return proto.CompactTextString(m) }
+func (*PlacementState) ProtoMessage()    {}
+func (*PlacementState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c7152227f82e815, []int{0}
+}
+
+func (m *PlacementState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlacementState.Unmarshal(m, b)
+}
+func (m *PlacementState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlacementState.Marshal(b, m, deterministic)
+}
+func (m *PlacementState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlacementState.Merge(m, src)
+}
+func (m *PlacementState) XXX_Size() int {
+	return xxx_messageInfo_PlacementState.Size(m)
+}
+func (m *PlacementState) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlacementState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlacementState proto.InternalMessageInfo
+
+func (m *PlacementState) GetSchemaVersion() uint32 {
+	if m != nil {
+		return m.SchemaVersion
+	}
+	return 0
+}
+
+func (m *PlacementState) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *PlacementState) GetTableGeneration() uint64 {
+	if m != nil {
+		return m.TableGeneration
+	}
+	return 0
+}
+
+func (m *PlacementState) GetMembers() []*PlacementMember {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
+func (m *PlacementState) GetEntityChangedAt() map[string]int64 {
+	if m != nil {
+		return m.EntityChangedAt
+	}
+	return nil
+}
+
+func (m *PlacementState) GetNamespaceGenerations() map[string]uint64 {
+	if m != nil {
+		return m.NamespaceGenerations
+	}
+	return nil
+}
+
+func (m *PlacementState) GetPins() map[string]*PlacementPins {
+	if m != nil {
+		return m.Pins
+	}
+	return nil
+}
+
+func (m *PlacementState) GetAliases() map[string]string {
+	if m != nil {
+		return m.Aliases
+	}
+	return nil
+}
+
+func (m *PlacementState) GetHostSetGenerations() map[string]uint64 {
+	if m != nil {
+		return m.HostSetGenerations
+	}
+	return nil
+}
+
+func (m *PlacementState) GetOrphanedAt() map[string]int64 {
+	if m != nil {
+		return m.OrphanedAt
+	}
+	return nil
+}
+
+func (m *PlacementState) GetGenerationPending() bool {
+	if m != nil {
+		return m.GenerationPending
+	}
+	return false
+}
+
+func (m *PlacementState) GetLastGenerationIndex() uint64 {
+	if m != nil {
+		return m.LastGenerationIndex
+	}
+	return 0
+}
+
+// PlacementMember is a Dapr runtime host in the placement state.
+type PlacementMember struct {
+	Name            string            `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	AppId           string            `protobuf:"bytes,2,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Namespace       string            `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Entities        []string          `protobuf:"bytes,4,rep,name=entities,proto3" json:"entities,omitempty"`
+	Labels          map[string]string `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Draining        bool              `protobuf:"varint,6,opt,name=draining,proto3" json:"draining,omitempty"`
+	Weight          int64             `protobuf:"varint,7,opt,name=weight,proto3" json:"weight,omitempty"`
+	EntityWeights   map[string]int64  `protobuf:"bytes,8,rep,name=entity_weights,json=entityWeights,proto3" json:"entity_weights,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	SkippedEntities []string          `protobuf:"bytes,9,rep,name=skipped_entities,json=skippedEntities,proto3" json:"skipped_entities,omitempty"`
+	Version         uint64            `protobuf:"varint,10,opt,name=version,proto3" json:"version,omitempty"`
+	// the times are in unix nanoseconds. Zero is the unset time.
+	CreatedAt            int64    `protobuf:"varint,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt            int64    `protobuf:"varint,12,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	DeletedAt            int64    `protobuf:"varint,13,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	RequestId            string   `protobuf:"bytes,14,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	PlacedAt             int64    `protobuf:"varint,15,opt,name=placed_at,json=placedAt,proto3" json:"placed_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PlacementMember) Reset()         { *m = PlacementMember{} }
+func (m *PlacementMember) String() string { return proto.CompactTextString(m) }
+func (*PlacementMember) ProtoMessage()    {}
+func (*PlacementMember) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c7152227f82e815, []int{1}
+}
+
+func (m *PlacementMember) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlacementMember.Unmarshal(m, b)
+}
+func (m *PlacementMember) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlacementMember.Marshal(b, m, deterministic)
+}
+func (m *PlacementMember) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlacementMember.Merge(m, src)
+}
+func (m *PlacementMember) XXX_Size() int {
+	return xxx_messageInfo_PlacementMember.Size(m)
+}
+func (m *PlacementMember) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlacementMember.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlacementMember proto.InternalMessageInfo
+
+func (m *PlacementMember) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *PlacementMember) GetAppId() string {
+	if m != nil {
+		return m.AppId
+	}
+	return ""
+}
+
+func (m *PlacementMember) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *PlacementMember) GetEntities() []string {
+	if m != nil {
+		return m.Entities
+	}
+	return nil
+}
+
+func (m *PlacementMember) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *PlacementMember) GetDraining() bool {
+	if m != nil {
+		return m.Draining
+	}
+	return false
+}
+
+func (m *PlacementMember) GetWeight() int64 {
+	if m != nil {
+		return m.Weight
+	}
+	return 0
+}
+
+func (m *PlacementMember) GetEntityWeights() map[string]int64 {
+	if m != nil {
+		return m.EntityWeights
+	}
+	return nil
+}
+
+func (m *PlacementMember) GetSkippedEntities() []string {
+	if m != nil {
+		return m.SkippedEntities
+	}
+	return nil
+}
+
+func (m *PlacementMember) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *PlacementMember) GetCreatedAt() int64 {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return 0
+}
+
+func (m *PlacementMember) GetUpdatedAt() int64 {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return 0
+}
+
+func (m *PlacementMember) GetDeletedAt() int64 {
+	if m != nil {
+		return m.DeletedAt
+	}
+	return 0
+}
+
+func (m *PlacementMember) GetRequestId() string {
+	if m != nil {
+		return m.RequestId
+	}
+	return ""
+}
+
+func (m *PlacementMember) GetPlacedAt() int64 {
+	if m != nil {
+		return m.PlacedAt
+	}
+	return 0
+}
+
+// PlacementPins maps the pinned actor IDs to their hosts.
+type PlacementPins struct {
+	Hosts                map[string]string `protobuf:"bytes,1,rep,name=hosts,proto3" json:"hosts,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *PlacementPins) Reset()         { *m = PlacementPins{} }
+func (m *PlacementPins) String() string { return proto.CompactTextString(m) }
+func (*PlacementPins) ProtoMessage()    {}
+func (*PlacementPins) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c7152227f82e815, []int{2}
+}
+
+func (m *PlacementPins) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PlacementPins.Unmarshal(m, b)
+}
+func (m *PlacementPins) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PlacementPins.Marshal(b, m, deterministic)
+}
+func (m *PlacementPins) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PlacementPins.Merge(m, src)
+}
+func (m *PlacementPins) XXX_Size() int {
+	return xxx_messageInfo_PlacementPins.Size(m)
+}
+func (m *PlacementPins) XXX_DiscardUnknown() {
+	xxx_messageInfo_PlacementPins.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PlacementPins proto.InternalMessageInfo
+
+func (m *PlacementPins) GetHosts() map[string]string {
+	if m != nil {
+		return m.Hosts
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*PlacementState)(nil), "dapr.proto.placement.v1.PlacementState")
+	proto.RegisterMapType((map[string]string)(nil), "dapr.proto.placement.v1.PlacementState.AliasesEntry")
+	proto.RegisterMapType((map[string]int64)(nil), "dapr.proto.placement.v1.PlacementState.EntityChangedAtEntry")
+	proto.RegisterMapType((map[string]uint64)(nil), "dapr.proto.placement.v1.PlacementState.HostSetGenerationsEntry")
+	proto.RegisterMapType((map[string]uint64)(nil), "dapr.proto.placement.v1.PlacementState.NamespaceGenerationsEntry")
+	proto.RegisterMapType((map[string]int64)(nil), "dapr.proto.placement.v1.PlacementState.OrphanedAtEntry")
+	proto.RegisterMapType((map[string]*PlacementPins)(nil), "dapr.proto.placement.v1.PlacementState.PinsEntry")
+	proto.RegisterType((*PlacementMember)(nil), "dapr.proto.placement.v1.PlacementMember")
+	proto.RegisterMapType((map[string]int64)(nil), "dapr.proto.placement.v1.PlacementMember.EntityWeightsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "dapr.proto.placement.v1.PlacementMember.LabelsEntry")
+	proto.RegisterType((*PlacementPins)(nil), "dapr.proto.placement.v1.PlacementPins")
+	proto.RegisterMapType((map[string]string)(nil), "dapr.proto.placement.v1.PlacementPins.HostsEntry")
+}
+
+func init() {
+	proto.RegisterFile("dapr/proto/placement/v1/placement_state.proto", fileDescriptor_5c7152227f82e815)
+}
+
+var fileDescriptor_5c7152227f82e815 = []byte{
+	// 795 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xdd, 0x6e, 0xe2, 0x46,
+	0x14, 0x16, 0xe1, 0xd7, 0x87, 0x00, 0xc9, 0x94, 0x34, 0x2e, 0x6d, 0x25, 0x14, 0xa9, 0x15, 0xbd,
+	0x88, 0x51, 0xd2, 0x56, 0x49, 0x93, 0x54, 0x2d, 0xa9, 0x50, 0x1a, 0x29, 0x4d, 0x91, 0x23, 0xed,
+	0xae, 0xf6, 0xc6, 0x1a, 0xf0, 0x08, 0x5b, 0x31, 0xb6, 0xe3, 0x19, 0xd8, 0xcd, 0x6b, 0xac, 0xb4,
+	0xcf, 0xb2, 0xaf, 0xb7, 0x9a, 0x33, 0xb6, 0x71, 0x7e, 0x10, 0xe6, 0x06, 0x79, 0xbe, 0xe3, 0xef,
+	0x3b, 0x73, 0x7e, 0x0d, 0x1c, 0xda, 0x34, 0x8c, 0xfa, 0x61, 0x14, 0x88, 0xa0, 0x1f, 0x7a, 0x74,
+	0xc2, 0x66, 0xcc, 0x17, 0xfd, 0xc5, 0xd1, 0xf2, 0x60, 0x71, 0x41, 0x05, 0x33, 0xf0, 0x15, 0xb2,
+	0x2f, 0x5f, 0x57, 0xcf, 0x46, 0xfa, 0x86, 0xb1, 0x38, 0x3a, 0xf8, 0x0c, 0xd0, 0x1c, 0x25, 0xc0,
+	0x9d, 0x64, 0x90, 0x9f, 0xa0, 0xc9, 0x27, 0x0e, 0x9b, 0x51, 0x6b, 0xc1, 0x22, 0xee, 0x06, 0xbe,
+	0x5e, 0xe8, 0x16, 0x7a, 0x0d, 0xb3, 0xa1, 0xd0, 0x37, 0x0a, 0x24, 0x6d, 0x28, 0xbb, 0xbe, 0xcd,
+	0x3e, 0xea, 0x5b, 0xdd, 0x42, 0xaf, 0x64, 0xaa, 0x03, 0xf9, 0x05, 0x76, 0x04, 0x1d, 0x7b, 0xcc,
+	0x9a, 0x32, 0x9f, 0x45, 0x54, 0x48, 0x7a, 0x11, 0x5f, 0x68, 0x21, 0x7e, 0x95, 0xc2, 0xe4, 0x12,
+	0xaa, 0x33, 0x36, 0x1b, 0xb3, 0x88, 0xeb, 0xa5, 0x6e, 0xb1, 0x57, 0x3f, 0xee, 0x19, 0x2b, 0x6e,
+	0x69, 0xa4, 0x37, 0xfc, 0x0f, 0x09, 0x66, 0x42, 0x24, 0x0e, 0xec, 0x32, 0x5f, 0xb8, 0xe2, 0xd1,
+	0x9a, 0x38, 0xd4, 0x9f, 0x32, 0xdb, 0xa2, 0x42, 0x2f, 0xa3, 0xda, 0xc5, 0x7a, 0x35, 0x8c, 0xd7,
+	0x18, 0xa2, 0xc0, 0x3f, 0x8a, 0x3f, 0x10, 0x43, 0x5f, 0x44, 0x8f, 0x66, 0x8b, 0x3d, 0x45, 0xc9,
+	0x02, 0xf6, 0x7c, 0x3a, 0x63, 0x3c, 0xa4, 0x93, 0x6c, 0x70, 0x5c, 0xaf, 0xa0, 0xb7, 0x41, 0x5e,
+	0x6f, 0xb7, 0x89, 0xc8, 0x32, 0x13, 0x5c, 0xb9, 0x6c, 0xfb, 0xaf, 0x98, 0xc8, 0x10, 0x4a, 0xa1,
+	0xeb, 0x73, 0xbd, 0x8a, 0x6e, 0x8e, 0xf2, 0xba, 0x19, 0xb9, 0x89, 0x2c, 0xd2, 0xc9, 0x2d, 0x54,
+	0xa9, 0xe7, 0x52, 0xce, 0xb8, 0x5e, 0x43, 0xa5, 0xdf, 0xf2, 0x2a, 0x0d, 0x14, 0x4d, 0x89, 0x25,
+	0x22, 0xe4, 0x01, 0xda, 0x4e, 0xc0, 0x85, 0xc5, 0x99, 0x78, 0x92, 0x0d, 0x0d, 0xc5, 0xff, 0xca,
+	0x2b, 0xfe, 0x6f, 0xc0, 0xc5, 0x1d, 0x13, 0x2f, 0x72, 0x41, 0x9c, 0x17, 0x06, 0xf2, 0x0e, 0xea,
+	0x41, 0x14, 0x3a, 0xd4, 0x57, 0x55, 0x06, 0xf4, 0x74, 0x92, 0xd7, 0xd3, 0xff, 0x31, 0x35, 0x29,
+	0x30, 0x04, 0x29, 0x40, 0x0e, 0x81, 0x2c, 0x63, 0xb0, 0x42, 0xe6, 0xdb, 0xae, 0x3f, 0xd5, 0xeb,
+	0xdd, 0x42, 0xaf, 0x66, 0xee, 0x2e, 0x2d, 0x23, 0x65, 0x20, 0xc7, 0xb0, 0xe7, 0x51, 0x9e, 0x8d,
+	0xdb, 0x52, 0x93, 0xb0, 0x8d, 0x8d, 0xfe, 0x8d, 0x34, 0x2e, 0x2f, 0x7e, 0x2d, 0x4d, 0x9d, 0x4b,
+	0x68, 0xbf, 0xd6, 0x67, 0x64, 0x07, 0x8a, 0xf7, 0xec, 0x11, 0x27, 0x4c, 0x33, 0xe5, 0xa3, 0x9c,
+	0xab, 0x05, 0xf5, 0xe6, 0x0c, 0xe7, 0xaa, 0x68, 0xaa, 0xc3, 0xd9, 0xd6, 0x69, 0xa1, 0x73, 0x05,
+	0xdf, 0xad, 0xec, 0x9e, 0x75, 0x42, 0xa5, 0xac, 0x90, 0x05, 0xda, 0xc8, 0x5d, 0x4d, 0xbc, 0xc8,
+	0x12, 0xeb, 0xc7, 0x3f, 0xaf, 0x4f, 0xb1, 0x54, 0xcb, 0x3a, 0x38, 0x83, 0xed, 0x6c, 0xdb, 0xac,
+	0xbb, 0x9c, 0x96, 0xe5, 0x0e, 0x61, 0x7f, 0x45, 0x57, 0x6c, 0x14, 0xe3, 0x9f, 0xd0, 0x7a, 0x56,
+	0xf2, 0x4d, 0x72, 0x7d, 0xf0, 0xa5, 0x0c, 0xad, 0x67, 0x5b, 0x87, 0x10, 0x28, 0xc9, 0x11, 0x8d,
+	0x05, 0xf0, 0x99, 0xec, 0x41, 0x85, 0x86, 0xa1, 0xe5, 0xda, 0x49, 0x20, 0x34, 0x0c, 0xaf, 0x6d,
+	0xf2, 0x03, 0x68, 0xe9, 0x34, 0xe3, 0xfe, 0xd3, 0xcc, 0x25, 0x40, 0x3a, 0x50, 0xc3, 0xf5, 0xe2,
+	0x32, 0xb5, 0xfa, 0x34, 0x33, 0x3d, 0x93, 0x1b, 0xa8, 0x78, 0x74, 0xcc, 0x3c, 0xae, 0x97, 0xf3,
+	0xce, 0xa9, 0xba, 0x9e, 0x71, 0x83, 0x34, 0xd5, 0xdd, 0xb1, 0x86, 0xf4, 0x64, 0x47, 0xd4, 0xf5,
+	0x65, 0x3f, 0x57, 0xb0, 0x9f, 0xd3, 0x33, 0xf9, 0x16, 0x2a, 0x1f, 0x98, 0x3b, 0x75, 0x84, 0x5e,
+	0xc5, 0xe8, 0xe3, 0x13, 0x19, 0x43, 0x33, 0xde, 0xa9, 0x0a, 0x48, 0x36, 0xc6, 0x79, 0xee, 0x9b,
+	0xa8, 0x4e, 0x7f, 0xab, 0xd8, 0xea, 0x42, 0x0d, 0x96, 0xc5, 0xe4, 0x67, 0x82, 0xdf, 0xbb, 0x61,
+	0xc8, 0x6c, 0x2b, 0xcd, 0x84, 0x86, 0x99, 0x68, 0xc5, 0xf8, 0x30, 0x49, 0x88, 0x0e, 0xd5, 0xe4,
+	0x3b, 0x04, 0x58, 0xe4, 0xe4, 0x48, 0x7e, 0x04, 0x98, 0x44, 0x8c, 0x0a, 0xb5, 0x0f, 0xea, 0x18,
+	0x84, 0x16, 0x23, 0x03, 0x21, 0xcd, 0xf3, 0xd0, 0x4e, 0xcc, 0xdb, 0xca, 0x1c, 0x23, 0xca, 0x6c,
+	0x33, 0x8f, 0xc5, 0xe6, 0x86, 0x32, 0xc7, 0x88, 0x32, 0x47, 0xec, 0x61, 0xce, 0xb8, 0x90, 0xc5,
+	0x6d, 0xaa, 0x12, 0xc6, 0xc8, 0xb5, 0x4d, 0xbe, 0x07, 0x0d, 0x53, 0x80, 0xe4, 0x16, 0x92, 0x6b,
+	0x0a, 0x18, 0x88, 0xce, 0x1f, 0x50, 0xcf, 0x14, 0x63, 0xa3, 0xee, 0xff, 0x1b, 0xc8, 0xcb, 0xec,
+	0x6d, 0xd4, 0xb9, 0x9f, 0x0a, 0xd0, 0x78, 0x32, 0x98, 0xe4, 0x0a, 0xca, 0x72, 0x9d, 0x72, 0xbd,
+	0x90, 0xf7, 0x1b, 0x22, 0x69, 0xb8, 0x9b, 0xe3, 0xea, 0x29, 0x7e, 0xe7, 0x14, 0x60, 0x09, 0x6e,
+	0x12, 0xd6, 0xe5, 0xc9, 0xfb, 0xdf, 0xa7, 0xae, 0x70, 0xe6, 0x63, 0x63, 0x12, 0xcc, 0xfa, 0xf8,
+	0xdf, 0x05, 0x7f, 0xc2, 0xfb, 0xe9, 0x2b, 0x7f, 0x62, 0xce, 0xd3, 0xc3, 0xb8, 0x82, 0xd6, 0x5f,
+	0xbf, 0x0e, 0x00, 0x3a, 0xa6, 0x21, 0xcf, 0xf0, 0x08, 0x00, 0x00,
+}